@@ -0,0 +1,108 @@
+package getopt
+
+import "fmt"
+import "sort"
+import "strings"
+
+// prefixIndex finds the long options starting with a given prefix,
+// without scanning all of them: the names are kept sorted, so that
+// all the options sharing a prefix are found next to each other.
+type prefixIndex struct {
+	sorted []string
+	order  map[string]int
+}
+
+// newPrefixIndex indexes the names of the long options, which must
+// be listed in the order of their declaration.
+func newPrefixIndex(names []string) *prefixIndex {
+	idx := &prefixIndex{
+		sorted: make([]string, len(names)),
+		order:  make(map[string]int, len(names)),
+	}
+	copy(idx.sorted, names)
+	sort.Strings(idx.sorted)
+	for i, name := range names {
+		idx.order[name] = i
+	}
+	return idx
+}
+
+// match returns the names starting with prefix, in the order of
+// their declaration, and whether prefix is itself one of the names.
+func (idx *prefixIndex) match(prefix string) (names []string, exact bool) {
+	return idx.matchN(prefix, 0)
+}
+
+// matchN works like match, but if limit is positive, stops looking
+// after limit+1 names, so that the cost is bounded no matter how many
+// names share the prefix.
+func (idx *prefixIndex) matchN(prefix string, limit int) (
+	names []string,
+	exact bool,
+) {
+	i := sort.SearchStrings(idx.sorted, prefix)
+	for j := i; j < len(idx.sorted); j++ {
+		if !strings.HasPrefix(idx.sorted[j], prefix) ||
+			limit > 0 && len(names) > limit {
+			break
+		}
+		names = append(names, idx.sorted[j])
+	}
+	exact = len(names) > 0 && names[0] == prefix
+	sort.Slice(names, func(a, b int) bool {
+		return idx.order[names[a]] < idx.order[names[b]]
+	})
+	return names, exact
+}
+
+// abbrev expands an unambiguous abbreviation of a long option in arg
+// to the full name of the option, keeping any attached argument. Any
+// ambiguity is resolved according to the policy. If limit is
+// positive, an abbreviation matching more than limit options is an
+// error, whatever the policy. The argument follows the assign
+// character (see Config.Assign).
+func abbrev(
+	arg string,
+	idx *prefixIndex,
+	policy Ambiguity,
+	limit int,
+	assign string,
+) (string, error) {
+	opt, rest := arg, ""
+	if i := strings.Index(arg, assign); i != -1 {
+		opt, rest = arg[:i], arg[i:]
+	}
+	candidates, exact := idx.matchN(opt, limit)
+	switch {
+	case exact || len(candidates) == 0:
+		return arg, nil
+	case limit > 0 && len(candidates) > limit:
+		return "", &ParseError{
+			Message: fmt.Sprintf(
+				"option is ambiguous (matches more than %d options)", limit),
+			Opt:        opt,
+			Unexpected: q(opt),
+			Expected:   fmt.Sprintf("a prefix of at most %d options", limit),
+			kind:       ErrAmbiguousOption,
+		}
+	case len(candidates) == 1 || policy == FirstDeclared:
+		return candidates[0] + rest, nil
+	case policy == Longest:
+		longest := candidates[0]
+		for _, long := range candidates[1:] {
+			if len(long) > len(longest) {
+				longest = long
+			}
+		}
+		return longest + rest, nil
+	}
+	return "", &ParseError{
+		Message: fmt.Sprintf(
+			"option is ambiguous (could be %s)",
+			strings.Join(candidates, ", ")),
+		Opt:        opt,
+		Unexpected: q(opt),
+		Expected:   "one of " + strings.Join(candidates, ", "),
+		kind:       ErrAmbiguousOption,
+	}
+}
@@ -0,0 +1,120 @@
+package getopt
+
+import "testing"
+import "errors"
+import "fmt"
+import "reflect"
+import "strings"
+
+// naiveMatch is the straightforward scan over all the names, which
+// prefixIndex must agree with.
+func naiveMatch(names []string, prefix string) (matches []string, exact bool) {
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+		if name == prefix {
+			exact = true
+		}
+	}
+	return matches, exact
+}
+
+// manyLongs generates a large number of long options, with plenty of
+// shared prefixes, declared out of the sorted order.
+func manyLongs(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("--opt-%d-%x", (n-i)%97, i)
+	}
+	return names
+}
+
+func Test_PrefixIndex_matchesNaive(t *testing.T) {
+	names := append(manyLongs(2000), "--opt", "--opt-1", "--o")
+	idx := newPrefixIndex(names)
+	for _, prefix := range []string{
+		"--", "--o", "--op", "--opt", "--opt-", "--opt-1", "--opt-1-",
+		"--opt-96-", "--opt-5-7cf", "--opt-5-7cf0", "--wizard", "--p",
+	} {
+		matches, exact := idx.match(prefix)
+		expected, expectedExact := naiveMatch(names, prefix)
+		if !reflect.DeepEqual(matches, expected) || exact != expectedExact {
+			t.Log("prefix", prefix)
+			t.Log("got", len(matches), exact)
+			t.Log("expected", len(expected), expectedExact)
+			t.Fatal("prefix index disagrees with the naive scan")
+		}
+	}
+}
+
+func Benchmark_Abbrev_large(b *testing.B) {
+	idx := newPrefixIndex(manyLongs(10000))
+	for i := 0; i < b.N; i++ {
+		if _, err := abbrev("--opt-47-7cf", idx, AmbiguityError, 0, "="); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_Abbrev_naive(b *testing.B) {
+	names := manyLongs(10000)
+	for i := 0; i < b.N; i++ {
+		if matches, _ := naiveMatch(names, "--opt-47-7cf"); len(matches) != 1 {
+			b.Fatal(matches)
+		}
+	}
+}
+
+func Test_Abbrev_limit(t *testing.T) {
+	var long []string
+	for _, name := range append(manyLongs(10000), "--opt") {
+		long = append(long, strings.TrimPrefix(name, "--"))
+	}
+	config := Config{AllowAbbrev: true, AbbrevLimit: 50, Ambiguity: FirstDeclared}
+	for _, prefix := range []string{"--o", "--opt-", "--opt-4"} {
+		_, err := GetOptResult([]string{prefix}, "", long, config)
+		errorQA(t, err)
+		if !errors.Is(err, ErrAmbiguousOption) {
+			t.Log("prefix", prefix)
+			t.Log("got", err)
+			t.Fatal("expected the prefix to exceed the limit")
+		}
+		if !strings.Contains(err.Error(), "more than 50 options") {
+			t.Log(err)
+			t.Fatal("recieved wrong error")
+		}
+	}
+	for _, arg := range []string{"--opt", "--opt-47-7cf", "--opt-47-7c"} {
+		r, err := GetOptResult([]string{arg}, "", long, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Options) != 1 {
+			t.Log("got", r.Options)
+			t.Fatal("expected a single option")
+		}
+	}
+	config.AbbrevLimit = 0
+	if _, err := GetOptResult([]string{"--opt-"}, "", long, config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Benchmark_Abbrev_limit(b *testing.B) {
+	idx := newPrefixIndex(manyLongs(10000))
+	for i := 0; i < b.N; i++ {
+		if _, err := abbrev("--o", idx, FirstDeclared, 50, "="); err == nil {
+			b.Fatal("expected an error")
+		}
+	}
+}
+
+func Benchmark_Abbrev_unlimited(b *testing.B) {
+	idx := newPrefixIndex(manyLongs(10000))
+	for i := 0; i < b.N; i++ {
+		if _, err := abbrev("--o", idx, FirstDeclared, 0, "="); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package getopt
+
+import "flag"
+import "fmt"
+import "reflect"
+import "strings"
+import "time"
+
+// binding stores the argument of an option in its destination, such
+// as a struct field, as the option is parsed.
+type binding func(optarg OptArg) error
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var valueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+
+// boolFlag is implemented by the flag.Values which take no argument,
+// same as in the flag package.
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+// Bind fills the fields of the struct pointed to by dst from args,
+// according to their struct tags (see CompileStruct), and returns the
+// leftover arguments, same as GetOpt. The fields of the options which
+// were not given keep their values, which can serve as defaults:
+//
+//	cfg := struct {
+//	    Verbose bool   `getopt:"-v,--verbose" help:"explain what is done"`
+//	    Output  string `getopt:"-o,--output" help:"write to FILE"`
+//	}{Output: "-"}
+//	args, err := getopt.Bind(&cfg, os.Args[1:])
+//
+// A programming error in the struct tags is returned as a ParseError,
+// same as from GetOptSafe. On any error, some of the fields may have
+// been set already.
+func Bind(dst interface{}, args []string) ([]string, error) {
+	spec, err := CompileStruct(dst)
+	if err != nil {
+		return nil, err
+	}
+	leftovers, _, err := spec.Parse(args)
+	return leftovers, err
+}
+
+// CompileStruct returns a Spec declaring the options described by the
+// struct tags of the struct pointed to by dst, which are then stored
+// in its fields each time an argument list is parsed with the Spec.
+//
+// The tag "getopt" lists the names of the option, separated with
+// commas, as in `getopt:"-o,--output"`; a short option and a long
+// option together are declared as aliases, as in "output|o". The tag
+// "help" sets the Description of the option. Fields without a getopt
+// tag are left alone.
+//
+// The type of the field decides what the option takes:
+//
+//   - A bool option takes no argument, and sets the field to true.
+//   - A string option takes an argument, as it is.
+//   - An int, int64, uint, uint64, float64 or time.Duration option
+//     takes an argument, converted to the type; an argument which
+//     cannot be converted is a ParseError.
+//   - A []string option takes an argument, and may be repeated;
+//     each argument is appended to the field.
+//   - A type implementing flag.Value (by a pointer to it) takes an
+//     argument, passed to its Set method (see Spec.Var); unless it
+//     has an IsBoolFlag method returning true, same as in the flag
+//     package.
+//
+// If the option is given more than once, the last occurrence wins,
+// same as with Options.Get (except for a []string).
+//
+// As the fields are set while parsing, a Spec compiled from a struct
+// must not be used by more than one goroutine at a time.
+func CompileStruct(dst interface{}) (*Spec, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, &ParseError{
+			Message:       "destination is not a pointer to a struct",
+			Unexpected:    fmt.Sprintf("%T", dst),
+			notUsersFault: true,
+			kind:          ErrInvalidSpec,
+		}
+	}
+	v = v.Elem()
+	type field struct {
+		name  string
+		value reflect.Value
+		help  string
+	}
+	var fields []field
+	var shortopts string
+	var longopts []string
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		tag, has := sf.Tag.Lookup("getopt")
+		if !has {
+			continue
+		}
+		if sf.PkgPath != "" {
+			return nil, &ParseError{
+				Message:       "struct field is not exported",
+				Opt:           sf.Name,
+				Unexpected:    q(tag),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			}
+		}
+		short, long, err := parse_tag(tag)
+		if err != nil {
+			return nil, err
+		}
+		suffix := "="
+		if !takesArg(sf.Type) {
+			suffix = ""
+		}
+		name := long
+		switch {
+		case long != "" && short != "":
+			longopts = append(longopts, long[2:]+"|"+short[1:]+suffix)
+		case long != "":
+			longopts = append(longopts, long[2:]+suffix)
+		default:
+			shortopts += short[1:] + strings.Replace(suffix, "=", ":", 1)
+			name = short
+		}
+		fields = append(fields, field{name, v.Field(i), sf.Tag.Get("help")})
+	}
+	spec, err := Compile(shortopts, longopts)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if err := spec.bind(f.name, f.value); err != nil {
+			return nil, err
+		}
+		if f.help != "" {
+			spec.Description(f.name, f.help)
+		}
+	}
+	return spec, nil
+}
+
+// takesArg reports whether an option stored in a struct field of
+// type t takes an argument.
+func takesArg(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(valueType) {
+		b, ok := reflect.New(t).Interface().(boolFlag)
+		return !ok || !b.IsBoolFlag()
+	}
+	return t.Kind() != reflect.Bool
+}
+
+// parse_tag parses the getopt struct tag, as in "-o,--output".
+func parse_tag(tag string) (short, long string, err error) {
+	for _, name := range strings.Split(tag, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case strings.HasPrefix(name, "--") && len(name) > 2 && long == "":
+			long = name
+		case len(name) > 1 && name[0] == '-' && name[1] != '-' &&
+			len([]rune(name)) == 2 && short == "":
+			short = name
+		default:
+			return "", "", &ParseError{
+				Message:       "struct tag does not declare an option",
+				Unexpected:    q(tag),
+				Expected:      "a short option, a long option, or both",
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			}
+		}
+	}
+	return short, long, nil
+}
+
+// StringVar makes the named options store their argument in dst,
+// each time an argument list is parsed with the Spec; the options
+// are still returned, same as without it. Along with the other Var
+// methods, this lets the program declare the destinations of the
+// options up front, as with the flag package:
+//
+//	spec, err := getopt.Compile("vo:", []string{"verbose|v", "output|o="})
+//	...
+//	spec.BoolVar(&verbose, "--verbose").StringVar(&output, "--output")
+//	args, _, err := spec.Parse(os.Args[1:])
+//
+// The options which were not given leave their destinations alone,
+// so these can be initialized with the defaults; unless the option
+// has a default set with Spec.Default, which is then stored. If an
+// option is given more than once, the last occurrence wins.
+//
+// As the destinations are set while parsing, a Spec with any Var
+// bindings must not be used by more than one goroutine at a time.
+func (s *Spec) StringVar(dst *string, names ...string) *Spec {
+	return s.vars(dst, names)
+}
+
+// BoolVar makes the named options set dst to true, or for an option
+// given with an argument, to the argument as parsed by
+// strconv.ParseBool. See StringVar.
+func (s *Spec) BoolVar(dst *bool, names ...string) *Spec {
+	return s.vars(dst, names)
+}
+
+// IntVar makes the named options store their argument in dst,
+// converted to an int; an argument which is not an integer is a
+// ParseError. For an option given without an argument, dst is
+// incremented instead, so that "-vvv" counts as 3. See StringVar.
+func (s *Spec) IntVar(dst *int, names ...string) *Spec {
+	return s.vars(dst, names)
+}
+
+// Float64Var makes the named options store their argument in dst,
+// converted to a float64; an argument which is not a number is a
+// ParseError. See StringVar.
+func (s *Spec) Float64Var(dst *float64, names ...string) *Spec {
+	return s.vars(dst, names)
+}
+
+// DurationVar makes the named options store their argument in dst,
+// as parsed by time.ParseDuration; an argument which is not a
+// duration is a ParseError. See StringVar.
+func (s *Spec) DurationVar(dst *time.Duration, names ...string) *Spec {
+	return s.vars(dst, names)
+}
+
+// StringsVar makes the named options append their argument to dst,
+// each time they are given, as in "-I include -I lib". See StringVar.
+func (s *Spec) StringsVar(dst *[]string, names ...string) *Spec {
+	return s.vars(dst, names)
+}
+
+// Var makes the named options pass their argument to the Set method
+// of value, each time they are given; so that any type implementing
+// flag.Value, such as a list of addresses or a log level, can be
+// reused as it is. An error from Set is returned as a ParseError
+// naming the option. An option given without an argument passes ""
+// to Set; or "true", if value has an IsBoolFlag method returning
+// true, same as in the flag package. See StringVar.
+func (s *Spec) Var(value flag.Value, names ...string) *Spec {
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		s.bindings[name] = append(s.bindings[name], valueSetter(value))
+	}
+	return s
+}
+
+// valueSetter returns the binding passing the arguments to value.
+func valueSetter(value flag.Value) binding {
+	return func(o OptArg) error {
+		arg := o.Argument
+		if b, ok := value.(boolFlag); ok && !o.HasArgument && b.IsBoolFlag() {
+			arg = "true"
+		}
+		if err := value.Set(arg); err != nil {
+			return &ParseError{
+				Message:    fmt.Sprintf("option has an invalid argument (%v)", err),
+				Opt:        o.Option,
+				Unexpected: q(arg),
+				Err:        err,
+				kind:       ErrInvalidArgument,
+			}
+		}
+		return nil
+	}
+}
+
+// Bindable lists the types which options can be bound to, with
+// BindVar, or converted to, with Get. The named types are converted
+// same as their underlying types, except for time.Duration, which is
+// parsed by time.ParseDuration.
+type Bindable interface {
+	~string | ~bool | ~int | ~int64 | ~uint | ~uint64 | ~float64 | ~[]string
+}
+
+// BindVar makes the named options store their argument in dst, same
+// as StringVar, IntVar and so on, for any of the Bindable types.
+func BindVar[T Bindable](s *Spec, dst *T, names ...string) *Spec {
+	return s.vars(dst, names)
+}
+
+// Get returns the argument of the named option, converted to T, same
+// as BindVar would store it: the last occurrence wins, an int counts
+// the occurrences given without an argument, and a []string collects
+// the arguments of all of them. An argument which cannot be converted
+// is a ParseError naming the option. If the option was not given at
+// all, Get returns the zero value and no error.
+func Get[T Bindable](opts Options, name string) (T, error) {
+	var value T
+	set := setter(reflect.ValueOf(&value).Elem())
+	for _, o := range opts {
+		if o.Option != name {
+			continue
+		}
+		if err := set(o); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	return value, nil
+}
+
+// vars binds the named options to the variable pointed to by dst.
+func (s *Spec) vars(dst interface{}, names []string) *Spec {
+	for _, name := range names {
+		if err := s.bind(name, reflect.ValueOf(dst).Elem()); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+
+// fallback stores the values of the bound options which were not
+// given, from the environment or their defaults (see Result.Lookup),
+// once the arguments are parsed. An option whose negation was given
+// counts as given, and is left alone.
+func (s *Spec) fallback(r *Result) error {
+	names := append(append([]string{}, s.shortNames...), s.longNames...)
+	for _, name := range names {
+		if _, has := s.aliases[name]; has || len(s.bindings[name]) == 0 ||
+			r.Has(name) {
+			continue
+		}
+		value, src := r.Lookup(name)
+		if src == Unset || src == CommandLine {
+			continue
+		}
+		optarg := OptArg{Option: name, Argument: value, HasArgument: true, Index: -1}
+		for _, bind := range s.bindings[name] {
+			if err := bind(optarg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bind makes the named option store its argument in dst, as it is
+// parsed; see CompileStruct for the supported types.
+func (s *Spec) bind(name string, dst reflect.Value) error {
+	name, _ = s.lookup(name)
+	set := setter(dst)
+	if set == nil {
+		return &ParseError{
+			Message:       "option cannot be stored in a " + dst.Type().String(),
+			Opt:           name,
+			Unexpected:    dst.Type().String(),
+			notUsersFault: true,
+			kind:          ErrInvalidSpec,
+		}
+	}
+	s.bindings[name] = append(s.bindings[name], set)
+	if _, has := s.types[name]; !has {
+		if typ := typeName(dst); typ != "" {
+			s.types[name] = typ
+		}
+	}
+	return nil
+}
+
+// typeName names the type of the arguments stored in dst, for
+// Describe; or returns "" for a flag.Value, which may be anything.
+func typeName(dst reflect.Value) string {
+	if dst.CanAddr() && dst.Addr().Type().Implements(valueType) {
+		return ""
+	}
+	t := dst.Type()
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return "duration"
+	case t.Kind() == reflect.Bool:
+		return "bool"
+	case t.Kind() == reflect.String:
+		return "string"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		return "int"
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		return "uint"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "float"
+	}
+	return ""
+}
+
+// setter returns the binding storing the arguments in dst, or nil if
+// the type of dst is not supported.
+func setter(dst reflect.Value) binding {
+	if dst.CanAddr() {
+		if value, ok := dst.Addr().Interface().(flag.Value); ok {
+			return valueSetter(value)
+		}
+	}
+	t := dst.Type()
+	switch {
+	case t == durationType:
+		return func(o OptArg) error {
+			d, err := parseDuration(o.Option, o.Argument)
+			if err != nil {
+				return err
+			}
+			dst.SetInt(int64(d))
+			return nil
+		}
+	case t.Kind() == reflect.Bool:
+		return func(o OptArg) error {
+			if !o.HasArgument {
+				dst.SetBool(true)
+				return nil
+			}
+			b, err := parseBool(o.Option, o.Argument)
+			if err != nil {
+				return err
+			}
+			dst.SetBool(b)
+			return nil
+		}
+	case t.Kind() == reflect.String:
+		return func(o OptArg) error {
+			dst.SetString(o.Argument)
+			return nil
+		}
+	case t.Kind() == reflect.Int || t.Kind() == reflect.Int64:
+		return func(o OptArg) error {
+			if !o.HasArgument {
+				dst.SetInt(dst.Int() + 1)
+				return nil
+			}
+			n, err := parseInt(o.Option, o.Argument, t.Bits())
+			if err != nil {
+				return err
+			}
+			dst.SetInt(n)
+			return nil
+		}
+	case t.Kind() == reflect.Uint || t.Kind() == reflect.Uint64:
+		return func(o OptArg) error {
+			n, err := parseUint(o.Option, o.Argument, t.Bits())
+			if err != nil {
+				return err
+			}
+			dst.SetUint(n)
+			return nil
+		}
+	case t.Kind() == reflect.Float64:
+		return func(o OptArg) error {
+			f, err := parseFloat(o.Option, o.Argument, t.Bits())
+			if err != nil {
+				return err
+			}
+			dst.SetFloat(f)
+			return nil
+		}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		return func(o OptArg) error {
+			arg := reflect.ValueOf(o.Argument).Convert(t.Elem())
+			dst.Set(reflect.Append(dst, arg))
+			return nil
+		}
+	}
+	return nil
+}
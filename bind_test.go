@@ -0,0 +1,362 @@
+package getopt
+
+import "testing"
+import "errors"
+import "fmt"
+import "net"
+import "reflect"
+import "strings"
+import "time"
+
+func Test_Bind(t *testing.T) {
+	type config struct {
+		Verbose bool          `getopt:"-v,--verbose" help:"explain what is done"`
+		Output  string        `getopt:"-o,--output"`
+		Count   int           `getopt:"--count"`
+		Size    uint64        `getopt:"--size"`
+		Ratio   float64       `getopt:"--ratio"`
+		Timeout time.Duration `getopt:"-t"`
+		Include []string      `getopt:"-I"`
+		Quiet   bool          `getopt:"-q"`
+		Skipped string
+	}
+	cfg := config{Output: "-", Skipped: "fizzy"}
+	args, err := Bind(&cfg, []string{
+		"-v", "--output=out.txt", "--count=-3", "--size=10",
+		"--ratio=1.5", "-t90s", "-Ia", "-I", "b", "--", "-q",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := config{
+		Verbose: true,
+		Output:  "out.txt",
+		Count:   -3,
+		Size:    10,
+		Ratio:   1.5,
+		Timeout: 90 * time.Second,
+		Include: []string{"a", "b"},
+		Skipped: "fizzy",
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Log("got", cfg)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong fields")
+	}
+	if !reflect.DeepEqual(args, []string{"-q"}) {
+		t.Log("got", args)
+		t.Fatal("recieved wrong leftovers")
+	}
+
+	spec, err := CompileStruct(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := spec.Usage("prog")
+	if usage != "Usage: prog [-q] [-t ARG] [-I ARG] [--verbose] "+
+		"[--output ARG] [--count ARG] [--size ARG] [--ratio ARG] [ARG...]" {
+		t.Log("got", usage)
+		t.Fatal("recieved wrong usage")
+	}
+	if spec.descriptions["--verbose"] != "explain what is done" {
+		t.Log("got", spec.descriptions)
+		t.Fatal("recieved wrong description")
+	}
+
+	for _, args := range [][]string{
+		{"--count=many"},
+		{"--size=-1"},
+		{"--ratio=half"},
+		{"-t", "soon"},
+	} {
+		cfg := config{}
+		_, err := Bind(&cfg, args)
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidArgument) {
+			t.Log("input", args)
+			t.Log("got", err)
+			t.Fatal("expected an invalid argument error")
+		}
+		if !reflect.DeepEqual(cfg, config{}) {
+			t.Log("input", args)
+			t.Log("got", cfg)
+			t.Fatal("expected the fields to be left alone")
+		}
+	}
+}
+
+func Test_Bind_errors(t *testing.T) {
+	var unsupported struct {
+		Table map[string]string `getopt:"--table"`
+	}
+	var unexported struct {
+		verbose bool `getopt:"-v"`
+	}
+	var badTag struct {
+		Verbose bool `getopt:"verbose"`
+	}
+	var twoShorts struct {
+		Verbose bool `getopt:"-v,-V"`
+	}
+	var duplicate struct {
+		Verbose bool `getopt:"-v"`
+		Version bool `getopt:"-v"`
+	}
+	for _, dst := range []interface{}{
+		&unsupported, &unexported, &badTag, &twoShorts, &duplicate,
+		unsupported, nil,
+	} {
+		_, err := Bind(dst, nil)
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Logf("input %T", dst)
+			t.Log("got", err)
+			t.Fatal("expected an invalid spec error")
+		}
+	}
+}
+
+func Test_Spec_Var(t *testing.T) {
+	spec, err := Compile("vI:", []string{
+		"output|o=", "ratio=", "timeout=", "color=?", "level=",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verbose, level int
+	var output = "-"
+	var ratio float64
+	var timeout time.Duration
+	var color bool
+	var include []string
+	spec.IntVar(&verbose, "-v").
+		StringVar(&output, "-o").
+		Float64Var(&ratio, "--ratio").
+		DurationVar(&timeout, "--timeout").
+		BoolVar(&color, "--color").
+		StringsVar(&include, "-I").
+		IntVar(&level, "--level")
+	args, opts, err := spec.Parse([]string{
+		"-vv", "--ratio=0.5", "-Ia", "--timeout=1m", "-v", "--color",
+		"-Ib", "--level=2", "fizzy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verbose != 3 || output != "-" || ratio != 0.5 || timeout != time.Minute ||
+		!color || !reflect.DeepEqual(include, []string{"a", "b"}) || level != 2 {
+		t.Log("got", verbose, output, ratio, timeout, color, include, level)
+		t.Fatal("recieved wrong variables")
+	}
+	if len(opts) != 9 || !reflect.DeepEqual(args, []string{"fizzy"}) {
+		t.Log("got", opts, args)
+		t.Fatal("expected the options and leftovers to be returned as well")
+	}
+
+	_, _, err = spec.Parse([]string{"--output", "out.txt", "--color=no"})
+	errorQA(t, err)
+	if output != "out.txt" || !errors.Is(err, ErrInvalidArgument) {
+		t.Log("got", output, err)
+		t.Fatal("expected an invalid boolean")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	spec.StringVar(&output, "--wizard")
+}
+
+func Test_BindVar_Get(t *testing.T) {
+	type level int
+	spec, err := Compile("vn:", []string{"timeout=", "level=", "tag="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n uint
+	var lvl level
+	var timeout time.Duration
+	BindVar(spec, &n, "-n")
+	BindVar(spec, &lvl, "--level")
+	BindVar(spec, &timeout, "--timeout")
+	_, opts, err := spec.Parse([]string{
+		"-vn", "4", "--level=2", "--timeout=2s", "-v", "--tag=a", "--tag=b",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || lvl != 2 || timeout != 2*time.Second {
+		t.Log("got", n, lvl, timeout)
+		t.Fatal("recieved wrong variables")
+	}
+
+	if v, err := Get[int](opts, "-v"); err != nil || v != 2 {
+		t.Log("got", v, err)
+		t.Fatal("expected -v to be counted")
+	}
+	if d, err := Get[time.Duration](opts, "--timeout"); err != nil || d != 2*time.Second {
+		t.Log("got", d, err)
+		t.Fatal("recieved wrong duration")
+	}
+	if tags, err := Get[[]string](opts, "--tag"); err != nil ||
+		!reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Log("got", tags, err)
+		t.Fatal("recieved wrong tags")
+	}
+	if f, err := Get[float64](opts, "--ratio"); err != nil || f != 0 {
+		t.Log("got", f, err)
+		t.Fatal("expected the zero value")
+	}
+	b, err := Get[bool](opts, "--tag")
+	errorQA(t, err)
+	if b || !errors.Is(err, ErrInvalidArgument) ||
+		err.Error() != "option expects a boolean: --tag" {
+		t.Log("got", b, err)
+		t.Fatal("expected an invalid argument error")
+	}
+}
+
+// addrs is a flag.Value collecting IP addresses.
+type addrs []net.IP
+
+func (a *addrs) String() string { return fmt.Sprint(*a) }
+
+func (a *addrs) Set(arg string) error {
+	ip := net.ParseIP(arg)
+	if ip == nil {
+		return fmt.Errorf("not an IP address")
+	}
+	*a = append(*a, ip)
+	return nil
+}
+
+// toggle is a flag.Value taking no argument.
+type toggle string
+
+func (v *toggle) String() string   { return string(*v) }
+func (v *toggle) IsBoolFlag() bool { return true }
+func (v *toggle) Set(arg string) error {
+	*v = toggle(arg)
+	return nil
+}
+
+func Test_Spec_Var_flagValue(t *testing.T) {
+	spec, err := Compile("t", []string{"listen|l="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listen addrs
+	var tog toggle
+	spec.Var(&listen, "-l").Var(&tog, "-t")
+	_, _, err = spec.Parse([]string{"-l", "127.0.0.1", "--listen=::1", "-t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listen.String() != "[127.0.0.1 ::1]" || tog != "true" {
+		t.Log("got", listen, tog)
+		t.Fatal("recieved wrong values")
+	}
+
+	_, _, err = spec.Parse([]string{"--listen=localhost"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) || !strings.Contains(err.Error(),
+		"option has an invalid argument (not an IP address): --listen") {
+		t.Log("got", err)
+		t.Fatal("expected an invalid argument error")
+	}
+
+	var cfg struct {
+		Listen addrs  `getopt:"-l"`
+		Toggle toggle `getopt:"--toggle"`
+	}
+	args, err := Bind(&cfg, []string{"-l", "10.0.0.1", "--toggle", "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Listen.String() != "[10.0.0.1]" || cfg.Toggle != "true" ||
+		!reflect.DeepEqual(args, []string{"x"}) {
+		t.Log("got", cfg, args)
+		t.Fatal("recieved wrong fields")
+	}
+}
+
+func Test_Spec_Default_bound(t *testing.T) {
+	spec, err := Compile("n:", []string{"mode=", "verbose", "ratio="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	var mode string
+	var verbose bool
+	spec.IntVar(&n, "-n").StringVar(&mode, "--mode").BoolVar(&verbose, "--verbose").
+		Default("-n", "3").Default("--mode", "fast").Default("--verbose", "true")
+	r, err := spec.ParseResult([]string{"--mode=slow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || mode != "slow" || !verbose {
+		t.Log("got", n, mode, verbose)
+		t.Fatal("recieved wrong variables")
+	}
+	if len(r.Options) != 1 {
+		t.Log("got", r.Options)
+		t.Fatal("expected the defaults not to be among the options")
+	}
+	for name, src := range map[string]Source{
+		"-n": Default, "--mode": CommandLine, "--ratio": Unset,
+	} {
+		if _, got := r.Lookup(name); got != src {
+			t.Log("option", name)
+			t.Log("got", got)
+			t.Fatal("recieved wrong source")
+		}
+	}
+
+	spec.Default("-n", "many")
+	_, err = spec.ParseResult(nil)
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Log("got", err)
+		t.Fatal("expected an invalid default to be an error")
+	}
+}
+
+func Test_Spec_Default_negated(t *testing.T) {
+	spec, err := Compile("", []string{"color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var color bool
+	spec.Negatable("--color").BoolVar(&color, "--color").Default("--color", "true")
+	r, err := spec.ParseResult([]string{"--no-color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if color {
+		t.Fatal("expected the negation to take precedence over the default")
+	}
+	if value, src := r.Lookup("--color"); value != "false" || src != CommandLine {
+		t.Log("got", value, src)
+		t.Fatal("recieved wrong effective value")
+	}
+	expected := "--color=false (command line)\n--no-color (command line)\n"
+	if got := r.Effective(); got != expected {
+		t.Log("got", got)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong effective configuration")
+	}
+
+	color = false
+	r, err = spec.ParseResult([]string{"--no-color", "--color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !color {
+		t.Fatal("expected the last of the two to win")
+	}
+	if value, src := r.Lookup("--color"); value != "" || src != CommandLine {
+		t.Log("got", value, src)
+		t.Fatal("recieved wrong effective value")
+	}
+}
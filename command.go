@@ -0,0 +1,367 @@
+package getopt
+
+import "fmt"
+import "io"
+import "strings"
+
+// Command is a command of a program with subcommands, such as "build"
+// in "prog build -v file"; or the program itself, at the root of the
+// tree of commands. Each command has its own options, and a function
+// running it:
+//
+//	build := &getopt.Command{
+//	    Name:    "build",
+//	    Summary: "compile the packages",
+//	    Spec:    buildSpec,
+//	    Run: func(r *getopt.Result) error {
+//	        ...
+//	    },
+//	}
+//	root := &getopt.Command{Spec: rootSpec}
+//	root.Add(build, test)
+//	if err := root.Execute(os.Args[1:]); err != nil {
+//	    ...
+//	}
+type Command struct {
+	// Name is the name of the command, as given on the command line.
+	// It is not used for the root command.
+	Name string
+
+	// Aliases are other names of the command, such as "rm" for
+	// "remove".
+	Aliases []string
+
+	// Summary is a one-line description of the command, for the
+	// list of commands in the help text (see PrintHelp).
+	Summary string
+
+	// Spec declares the options of the command; if nil, it takes
+	// none.
+	Spec *Spec
+
+	// Run runs the command, with the parsed options and operands
+	// following its name; Result.Parent has those of the command
+	// it is a subcommand of. A command with subcommands is only run
+	// if no subcommand is given.
+	Run func(r *Result) error
+
+	// Default names the subcommand run when the first operand is
+	// not the name of a subcommand (which is then an operand of
+	// the default command, along with any others), or when there
+	// are none, unless the command has a Run function of its own.
+	Default string
+
+	commands []*Command
+	globals  []string
+}
+
+// Global marks the named options of the command as global: they are
+// accepted by its subcommands as well (at any depth), so that both
+// "prog -v build" and "prog build -v" work. The global options given
+// before the name of a subcommand are included in the Result of the
+// subcommand, ahead of its own options, with an Index of -1 (as they
+// are not among its arguments); so that with Get, an option given
+// after the name wins over one given before it (and with Count, both
+// count). An option of the subcommand takes precedence over a global
+// option of the same name. Global panics if an option was not
+// declared by Spec.
+func (c *Command) Global(names ...string) *Command {
+	spec := c.spec()
+	for _, name := range names {
+		name, _ = spec.lookup(name)
+		c.globals = append(c.globals, name)
+	}
+	return c
+}
+
+// global is a set of global options, and the Spec declaring them.
+type global struct {
+	spec  *Spec
+	names []string
+}
+
+// Add adds the commands as subcommands of c. It panics if a name is
+// empty, or already used by another subcommand (as its name, or as an
+// alias).
+func (c *Command) Add(commands ...*Command) *Command {
+	for _, cmd := range commands {
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if name == "" || c.command(name) != nil {
+				panic(&ParseError{
+					Message:       "command specified more than once",
+					Opt:           name,
+					Unexpected:    q(name),
+					notUsersFault: true,
+					kind:          ErrInvalidSpec,
+				})
+			}
+		}
+		c.commands = append(c.commands, cmd)
+	}
+	return c
+}
+
+// command returns the subcommand of the given name (or alias), or nil.
+func (c *Command) command(name string) *Command {
+	for _, cmd := range c.commands {
+		if cmd.Name == name {
+			return cmd
+		}
+		for _, alias := range cmd.Aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// Execute parses args, and runs the command they select. The options
+// of c are parsed up to the first operand, which (if c has any
+// subcommands) is the name of the subcommand; the arguments which
+// follow it are then executed by the subcommand, and so on. A name
+// which is not a subcommand is a ParseError matching
+// ErrUnknownCommand, and so is a missing one (matching
+// ErrMissingCommand), unless c has a Run function of its own; or
+// unless c has a Default command, which is run instead. Any other
+// error is returned from parsing the options, or from Run.
+func (c *Command) Execute(args []string) error {
+	return c.execute(args, nil, nil)
+}
+
+func (c *Command) execute(args []string, parent *Result, globals []global) error {
+	spec := c.spec().inherit(globals)
+	if len(c.commands) > 0 {
+		spec.Permute = false
+	}
+	var inherited Options
+	if parent != nil {
+		declared := make(map[string]bool)
+		for _, g := range globals {
+			for _, name := range g.spec.globalNames(g.names) {
+				declared[name] = true
+			}
+		}
+		for _, optarg := range parent.Options {
+			if declared[optarg.Option] {
+				optarg.Index = -1
+				inherited = append(inherited, optarg)
+			}
+		}
+	}
+	r, err := spec.parseResult(args, inherited)
+	if err != nil {
+		return err
+	}
+	r.parent = parent
+	if len(c.commands) == 0 || len(r.Args()) == 0 && c.Run != nil {
+		if c.Run == nil {
+			return nil
+		}
+		return c.Run(r)
+	}
+	var def *Command
+	if c.Default != "" {
+		if def = c.command(c.Default); def == nil {
+			return &ParseError{
+				Message:       "default command not declared",
+				Opt:           c.Default,
+				Unexpected:    q(c.Default),
+				Expected:      "one of " + strings.Join(c.names(), ", "),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			}
+		}
+	}
+	if len(c.globals) > 0 {
+		globals = append(globals[:len(globals):len(globals)], global{c.spec(), c.globals})
+	}
+	if len(r.Args()) == 0 {
+		if def != nil {
+			return def.execute(nil, r, globals)
+		}
+		return &ParseError{
+			Message:  "command is required",
+			Expected: "one of " + strings.Join(c.names(), ", "),
+			kind:     ErrMissingCommand,
+		}
+	}
+	name := r.Args()[0]
+	cmd := c.command(name)
+	if cmd == nil && def != nil {
+		return def.execute(r.Args(), r, globals)
+	} else if cmd == nil {
+		return &ParseError{
+			Message:    "command not recognized",
+			Opt:        name,
+			Unexpected: q(name),
+			Expected:   "one of " + strings.Join(c.names(), ", "),
+			kind:       ErrUnknownCommand,
+		}
+	}
+	return cmd.execute(r.Args()[1:], r, globals)
+}
+
+// spec returns the Spec of the command, or an empty one.
+func (c *Command) spec() *Spec {
+	if c.Spec != nil {
+		return c.Spec
+	}
+	spec, _ := Compile("", nil)
+	return spec
+}
+
+// inherit returns a copy of s, which also accepts the global options,
+// unless s declares options of the same names; for Command.
+func (s *Spec) inherit(globals []global) *Spec {
+	c := *s
+	c.shorts, c.longs, c.aliases = cloneMap(s.shorts), cloneMap(s.longs), cloneMap(s.aliases)
+	c.folded = cloneMap(s.folded)
+	c.shortNames = append([]string{}, s.shortNames...)
+	c.longNames = append([]string{}, s.longNames...)
+	c.rest, c.literal, c.hidden = cloneMap(s.rest), cloneMap(s.literal), cloneMap(s.hidden)
+	c.sections, c.descriptions = cloneMap(s.sections), cloneMap(s.descriptions)
+	c.metavars, c.types, c.defaults = cloneMap(s.metavars), cloneMap(s.types), cloneMap(s.defaults)
+	c.values, c.env, c.negated = cloneMap(s.values), cloneMap(s.env), cloneMap(s.negated)
+	c.countMax, c.intercepts = cloneMap(s.countMax), cloneMap(s.intercepts)
+	c.completers, c.validators = cloneMap(s.completers), cloneMap(s.validators)
+	c.bindings, c.configured = cloneMap(s.bindings), cloneMap(s.configured)
+	for _, g := range globals {
+		for _, name := range g.spec.globalNames(g.names) {
+			_, long := c.longs[name]
+			_, short := c.shorts[name]
+			if long || short {
+				continue
+			}
+			from := g.spec
+			if ar, has := from.longs[name]; has {
+				c.longs[name] = ar
+				c.longNames = append(c.longNames, name)
+				lower := strings.ToLower(name)
+				c.folded[lower] = append(c.folded[lower], name)
+			} else {
+				c.shorts[name] = from.shorts[name]
+				c.shortNames = append(c.shortNames, name)
+			}
+			if long, has := from.aliases[name]; has {
+				c.aliases[name] = long
+			}
+			inheritKey(c.rest, from.rest, name)
+			inheritKey(c.literal, from.literal, name)
+			inheritKey(c.hidden, from.hidden, name)
+			inheritKey(c.sections, from.sections, name)
+			inheritKey(c.descriptions, from.descriptions, name)
+			inheritKey(c.metavars, from.metavars, name)
+			inheritKey(c.types, from.types, name)
+			inheritKey(c.defaults, from.defaults, name)
+			inheritKey(c.configured, from.configured, name)
+			inheritKey(c.values, from.values, name)
+			inheritKey(c.env, from.env, name)
+			inheritKey(c.negated, from.negated, name)
+			inheritKey(c.countMax, from.countMax, name)
+			inheritKey(c.intercepts, from.intercepts, name)
+			inheritKey(c.completers, from.completers, name)
+			inheritKey(c.validators, from.validators, name)
+			inheritKey(c.bindings, from.bindings, name)
+		}
+	}
+	c.index = newPrefixIndex(c.longNames)
+	return &c
+}
+
+// globalNames lists the names of the global options, along with their
+// aliases and negations, in the order of their declaration.
+func (s *Spec) globalNames(globals []string) []string {
+	global := make(map[string]bool)
+	for _, name := range globals {
+		global[name] = true
+	}
+	var names []string
+	for _, name := range s.shortNames {
+		if global[name] || global[s.aliases[name]] {
+			names = append(names, name)
+		}
+	}
+	for _, name := range s.longNames {
+		if global[name] || global[s.negated[name]] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	clone := make(map[K]V, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+func inheritKey[V any](dst, src map[string]V, key string) {
+	if v, has := src[key]; has {
+		dst[key] = v
+	}
+}
+
+// names lists the names of the subcommands.
+func (c *Command) names() []string {
+	names := make([]string, len(c.commands))
+	for i, cmd := range c.commands {
+		names[i] = cmd.Name
+	}
+	return names
+}
+
+// PrintHelp writes a help text for the command to w, same as
+// Spec.PrintHelp, followed by the list of the subcommands, with their
+// summaries (and aliases), if there are any:
+//
+//	Usage: prog [-v] COMMAND [ARG...]
+//
+//	Options:
+//	  -v  explain what is done
+//
+//	Commands:
+//	  build  compile the packages
+//	  test   run the tests
+//
+// For a subcommand, prog should include the name of the command, as
+// in "prog build".
+func (c *Command) PrintHelp(w io.Writer, prog string) error {
+	spec := c.spec()
+	if len(c.commands) == 0 {
+		return spec.PrintHelp(w, prog)
+	}
+	operands := "COMMAND [ARG...]"
+	if c.Run != nil || c.Default != "" {
+		operands = "[COMMAND] [ARG...]"
+	}
+	items := append([]string{prog}, spec.synopsisOptions("ARG")...)
+	var b strings.Builder
+	b.WriteString("Usage: " + strings.Join(append(items, operands), " ") + "\n")
+	b.WriteString(spec.helpOptions())
+	width := 0
+	names := make([]string, len(c.commands))
+	for i, cmd := range c.commands {
+		names[i] = strings.Join(append([]string{cmd.Name}, cmd.Aliases...), ", ")
+		if c.Default != "" && c.command(c.Default) == cmd {
+			names[i] += " (default)"
+		}
+		if n := len([]rune(names[i])); n > width {
+			width = n
+		}
+	}
+	b.WriteString("\nCommands:\n")
+	for i, cmd := range c.commands {
+		line := fmt.Sprintf("  %-*s  %s", width, names[i], cmd.Summary)
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Parent returns the result of parsing the options of the command
+// which the command run with this result is a subcommand of (see
+// Command), or nil.
+func (r *Result) Parent() *Result { return r.parent }
@@ -0,0 +1,236 @@
+package getopt
+
+import "testing"
+import "errors"
+import "reflect"
+import "strings"
+
+func Test_Command(t *testing.T) {
+	unsetPOSIXLY(t)
+	rootSpec, err := Compile("v", []string{"config="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootSpec.Permute = true
+	buildSpec, err := Compile("o:", []string{"race"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buildSpec.Permute = true
+	var ran string
+	var got *Result
+	root := &Command{Spec: rootSpec}
+	root.Add(&Command{
+		Name:    "build",
+		Summary: "compile the packages",
+		Spec:    buildSpec,
+		Run: func(r *Result) error {
+			ran, got = "build", r
+			return nil
+		},
+	}, &Command{
+		Name:    "test",
+		Summary: "run the tests",
+		Run: func(r *Result) error {
+			ran, got = "test", r
+			return errors.New("tests failed")
+		},
+	})
+
+	err = root.Execute([]string{"-v", "build", "-o", "out", "./...", "--race"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran != "build" || !got.Has("-o") || !got.Has("--race") ||
+		!reflect.DeepEqual(got.Args(), []string{"./..."}) ||
+		!got.Parent().Has("-v") || got.Parent().Parent() != nil {
+		t.Log("got", ran, got.Options, got.Args(), got.Parent())
+		t.Fatal("expected build to run")
+	}
+
+	err = root.Execute([]string{"test", "-x"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrUnknownOption) {
+		t.Log("got", err)
+		t.Fatal("expected an unknown option")
+	}
+	if err := root.Execute([]string{"test"}); err == nil || err.Error() != "tests failed" {
+		t.Log("got", err)
+		t.Fatal("expected the error from Run")
+	}
+
+	for input, kind := range map[string]error{
+		"deploy": ErrUnknownCommand,
+		"-v":     ErrMissingCommand,
+	} {
+		err := root.Execute([]string{input})
+		errorQA(t, err)
+		if !errors.Is(err, kind) {
+			t.Log("input", input)
+			t.Log("got", err)
+			t.Fatal("expected a command error")
+		}
+	}
+	err = root.Execute([]string{"deploy"})
+	if err.Error() != "command not recognized: deploy" {
+		t.Log("got", err)
+		t.Fatal("recieved wrong error")
+	}
+
+	var b strings.Builder
+	if err := root.PrintHelp(&b, "prog"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Usage: prog [-v] [--config ARG] COMMAND [ARG...]\n" +
+		"\n" +
+		"Options:\n" +
+		"  -v\n" +
+		"      --config=ARG\n" +
+		"\n" +
+		"Commands:\n" +
+		"  build  compile the packages\n" +
+		"  test   run the tests\n"
+	if b.String() != expected {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong help")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	root.Add(&Command{Name: "test"})
+}
+
+func Test_Command_Global(t *testing.T) {
+	rootSpec, err := Compile("", []string{"verbose|v", "config=", "color", "dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config string
+	rootSpec.Negatable("--color").StringVar(&config, "--config").Default("--config", "default.conf")
+	buildSpec, err := Compile("o:", []string{"dry-run="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *Result
+	run := func(r *Result) error {
+		got = r
+		return nil
+	}
+	root := &Command{Spec: rootSpec}
+	root.Global("-v", "--config", "--color", "--dry-run").Add(
+		&Command{Name: "build", Spec: buildSpec, Run: run},
+		&Command{Name: "test", Run: run},
+	)
+
+	for _, args := range [][]string{
+		{"-v", "--config=a.conf", "build", "-o", "out"},
+		{"build", "-v", "-o", "out", "--config", "a.conf"},
+		{"--config=b.conf", "build", "--verbose", "--config=a.conf", "-o", "out"},
+	} {
+		got, config = nil, ""
+		if err := root.Execute(args); err != nil {
+			t.Log("input", args)
+			t.Fatal(err)
+		}
+		if arg, _ := got.Get("--config"); !got.Has("--verbose") || arg != "a.conf" ||
+			config != "a.conf" || !got.Has("-o") {
+			t.Log("input", args)
+			t.Log("got", got.Options, config)
+			t.Fatal("expected the global options to be inherited")
+		}
+	}
+
+	if err := root.Execute([]string{"--color", "test", "--no-color"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{{Option: "--color", Index: -1}, {Option: "--no-color", Index: 0}}
+	if !reflect.DeepEqual(got.Options, expected) {
+		t.Log("got", got.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if on, given := got.Flag("--color"); on || !given || config != "default.conf" {
+		t.Log("got", got.Options, config)
+		t.Fatal("expected the negation to win, and the default to be bound")
+	}
+
+	err = root.Execute([]string{"build", "--dry-run"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrMissingArgument) {
+		t.Log("got", err)
+		t.Fatal("expected the option of build to take precedence")
+	}
+	if err := root.Execute([]string{"test", "--dry-run"}); err != nil || !got.Has("--dry-run") {
+		t.Log("got", err)
+		t.Fatal("expected test to inherit --dry-run")
+	}
+}
+
+func Test_Command_Aliases_Default(t *testing.T) {
+	var ran string
+	var args []string
+	command := func(name string, aliases ...string) *Command {
+		return &Command{Name: name, Aliases: aliases, Summary: name + " files",
+			Run: func(r *Result) error {
+				ran, args = name, r.Args()
+				return nil
+			}}
+	}
+	root := &Command{Default: "add"}
+	root.Add(command("add"), command("remove", "rm", "del"))
+	for _, tc := range []struct {
+		args     []string
+		ran      string
+		expected []string
+	}{
+		{[]string{"rm", "a"}, "remove", []string{"a"}},
+		{[]string{"del"}, "remove", []string{}},
+		{[]string{"remove", "rm"}, "remove", []string{"rm"}},
+		{[]string{"a", "b"}, "add", []string{"a", "b"}},
+		{nil, "add", nil},
+	} {
+		ran, args = "", nil
+		if err := root.Execute(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		if ran != tc.ran || !reflect.DeepEqual(args, tc.expected) {
+			t.Log("input", tc.args)
+			t.Log("got", ran, args)
+			t.Fatal("recieved wrong command")
+		}
+	}
+
+	var b strings.Builder
+	if err := root.PrintHelp(&b, "prog"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Usage: prog [COMMAND] [ARG...]\n" +
+		"\n" +
+		"Commands:\n" +
+		"  add (default)    add files\n" +
+		"  remove, rm, del  remove files\n"
+	if b.String() != expected {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong help")
+	}
+
+	root.Default = "wizard"
+	err := root.Execute([]string{"a"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Log("got", err)
+		t.Fatal("expected an invalid spec")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	root.Add(command("delete", "del"))
+}
@@ -0,0 +1,548 @@
+package getopt
+
+import "fmt"
+import "io"
+import "strings"
+
+// CompletionWords returns the names of all declared long options, in
+// the order of their declaration, followed by the short options if
+// shorts is set. The list is suitable for passing to the bash
+// "compgen -W" builtin, as a building block for a completion
+// function:
+//
+//	COMPREPLY=($(compgen -W "--help --verbose" -- "$cur"))
+func (p *Parser) CompletionWords(shorts bool) []string {
+	words := append([]string{}, p.spec.longNames...)
+	if shorts {
+		words = append(words, p.spec.shortNames...)
+	}
+	return words
+}
+
+// CompletionValues returns the values allowed for the argument of the
+// named option by Spec.WithValues, filtered by prefix; e.g. for
+// completing "--color=a" in a shell. It returns nil for an option
+// which allows any argument.
+func (p *Parser) CompletionValues(name, prefix string) []string {
+	name, _ = p.spec.lookup(name)
+	var words []string
+	for _, value := range p.spec.values[name] {
+		if strings.HasPrefix(value, prefix) {
+			words = append(words, value)
+		}
+	}
+	return words
+}
+
+// PrintCompletion writes a script to w, which makes the given shell
+// complete the command line of prog: the names of the options, and
+// their arguments. The shells supported are "bash", "zsh", "fish" and
+// "powershell"; any other is a ParseError. The script is meant to be
+// installed where the shell finds it, e.g. in
+// /usr/share/bash-completion/completions, as "_prog" in a directory on
+// zsh's $fpath, or as "prog.fish" in ~/.config/fish/completions; for
+// PowerShell, it is meant to be sourced from the $PROFILE:
+//
+//	prog --generate-completion=bash > /usr/share/bash-completion/completions/prog
+//
+// (see CompletionHook). The arguments are completed by the functions
+// registered with Complete, or else with the values allowed by
+// WithValues, with "true" and "false" for the options checked with
+// ValidBool (or bound to a bool), and not at all for other types known
+// to Describe, such as numbers; any other argument, and the operands,
+// are completed as file names. The Hidden options are left out. For
+// zsh, fish and PowerShell, the options are listed with their
+// descriptions; for zsh, the options declared Exclusive (and the
+// aliases, and the negations, of the same option) are not offered
+// together.
+func (s *Spec) PrintCompletion(w io.Writer, prog, shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = s.bashCompletion(prog)
+	case "zsh":
+		script = s.zshCompletion(prog)
+	case "fish":
+		script = s.fishCompletion(prog)
+	case "powershell":
+		script = s.powershellCompletion(prog)
+	default:
+		return &ParseError{
+			Message:    "shell not supported for completion",
+			Opt:        shell,
+			Unexpected: q(shell),
+			Expected:   "one of " + strings.Join(completionShells, ", "),
+			kind:       ErrInvalidArgument,
+		}
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// completionShells lists the shells supported by PrintCompletion.
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// CompletionHook declares a hidden --generate-completion option, which
+// takes the name of a shell, and makes the parser intercept it: rather
+// than returning it, it prints the completion script for prog to
+// Stdout (see PrintCompletion), and returns a ParseError matching
+// ErrCompletion, upon which the program should exit successfully:
+//
+//	spec.CompletionHook("prog")
+//	args, opts, err := spec.Parse(os.Args[1:])
+//	if errors.Is(err, getopt.ErrCompletion) {
+//	    os.Exit(0)
+//	}
+//
+// If the script cannot be written, the ParseError still matches
+// ErrCompletion, and holds the write error in its Err.
+func (s *Spec) CompletionHook(prog string) *Spec {
+	s.declareLong("--generate-completion", argRequired)
+	s.WithValues("--generate-completion", completionShells...).
+		Hidden("--generate-completion")
+	s.intercepts["--generate-completion"] = func(optarg OptArg) error {
+		err := s.PrintCompletion(s.stdout(), prog, optarg.Argument)
+		if _, ok := err.(*ParseError); err != nil && !ok {
+			return &ParseError{
+				Message:       "completion script cannot be written",
+				Opt:           optarg.Option,
+				Unexpected:    err.Error(),
+				Err:           err,
+				notUsersFault: true,
+				kind:          ErrCompletion,
+			}
+		} else if err != nil {
+			return err
+		}
+		return &ParseError{
+			Message: "completion requested",
+			Opt:     optarg.Option,
+			kind:    ErrCompletion,
+		}
+	}
+	return s
+}
+
+// Complete registers a function completing the argument of the named
+// option, given the prefix typed so far; such as for branch names, or
+// host names, which can't be listed in advance (see WithValues). The
+// completion scripts (see PrintCompletion) call it by running the
+// program with a hidden --complete-values option, as in
+// "prog --complete-values=--branch=fix/", which prints the candidates
+// returned by complete, one per line, to Stdout; the parser then
+// returns a ParseError matching ErrCompletion, same as with
+// CompletionHook. The candidates are meant to start with the prefix.
+func (s *Spec) Complete(name string, complete func(prefix string) []string) *Spec {
+	name, _ = s.lookup(name)
+	s.completers[name] = complete
+	s.declareLong("--complete-values", argRequired)
+	s.Hidden("--complete-values")
+	s.intercepts["--complete-values"] = func(optarg OptArg) error {
+		name, prefix := optarg.Argument, ""
+		if i := strings.Index(optarg.Argument, "="); i > 0 {
+			name, prefix = optarg.Argument[:i], optarg.Argument[i+1:]
+		}
+		if long, has := s.aliases[name]; has {
+			name = long
+		}
+		if complete, has := s.completers[name]; has {
+			for _, candidate := range complete(prefix) {
+				fmt.Fprintln(s.stdout(), candidate)
+			}
+		}
+		return &ParseError{
+			Message: "completion requested",
+			Opt:     optarg.Option,
+			kind:    ErrCompletion,
+		}
+	}
+	return s
+}
+
+// dynamic returns the name under which the argument of opt is
+// completed by a function registered with Complete, or "" if none is.
+func (s *Spec) dynamic(opt OptionSpec) string {
+	name := opt.Long
+	if name == "" {
+		name = opt.Short
+	}
+	if _, has := s.completers[name]; has {
+		return name
+	}
+	return ""
+}
+
+// completed lists the options offered for completion: all but the
+// Hidden ones.
+func (s *Spec) completed() []OptionSpec {
+	var opts []OptionSpec
+	for _, opt := range s.describe() {
+		if !opt.Hidden {
+			opts = append(opts, opt)
+		}
+	}
+	return opts
+}
+
+// completionValues returns the words completing the argument of opt,
+// and whether it should be completed as a file name instead (if there
+// are none).
+func completionValues(opt OptionSpec) ([]string, bool) {
+	switch {
+	case opt.Values != nil:
+		return opt.Values, false
+	case opt.Type == "bool":
+		return []string{"true", "false"}, false
+	case opt.Type == "" || opt.Type == "string":
+		return nil, true
+	}
+	return nil, false
+}
+
+// bashCompletion renders the completion script for bash. The script
+// copes with bash splitting "--color=auto" into three words, as "=" is
+// in COMP_WORDBREAKS.
+func (s *Spec) bashCompletion(prog string) string {
+	var b strings.Builder
+	var words []string
+	fn := "_" + identifier(prog) + "_completion"
+	fmt.Fprintf(&b, "# bash completion for %s\n", prog)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]} eq=\n" +
+		"    if [[ $cur == = ]]; then\n" +
+		"        cur= eq='='\n" +
+		"    elif [[ $prev == = && $COMP_CWORD -gt 1 ]]; then\n" +
+		"        prev=${COMP_WORDS[COMP_CWORD-2]} eq='='\n" +
+		"    fi\n" +
+		"    case $eq$prev in\n")
+	for _, opt := range s.completed() {
+		var patterns []string
+		if opt.Short != "" {
+			words = append(words, opt.Short)
+			if opt.TakesArg && !opt.Optional {
+				patterns = append(patterns, opt.Short)
+			}
+		}
+		if opt.Long != "" {
+			words = append(words, opt.Long)
+			if opt.TakesArg && !opt.Optional {
+				patterns = append(patterns, opt.Long)
+			}
+			if opt.TakesArg {
+				patterns = append(patterns, "="+opt.Long)
+			}
+		}
+		if len(patterns) == 0 {
+			continue
+		}
+		reply := "COMPREPLY=()"
+		if name := s.dynamic(opt); name != "" {
+			reply = `mapfile -t COMPREPLY < <("${COMP_WORDS[0]}" ` +
+				`--complete-values=` + name + `="$cur" 2>/dev/null)`
+		} else if values, files := completionValues(opt); files {
+			reply = `COMPREPLY=($(compgen -f -- "$cur"))`
+		} else if values != nil {
+			reply = `COMPREPLY=($(compgen -W ` + shellQuote(strings.Join(values, " ")) +
+				` -- "$cur"))`
+		}
+		fmt.Fprintf(&b, "        %s)\n", strings.Join(patterns, "|"))
+		fmt.Fprintf(&b, "            %s\n", reply)
+		b.WriteString("            return\n            ;;\n")
+	}
+	b.WriteString("    esac\n" +
+		"    if [[ $cur == -* ]]; then\n" +
+		"        COMPREPLY=($(compgen -W " + shellQuote(strings.Join(words, " ")) + ` -- "$cur"))` + "\n" +
+		"        return\n" +
+		"    fi\n" +
+		`    COMPREPLY=($(compgen -f -- "$cur"))` + "\n" +
+		"}\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, shellQuote(prog))
+	return b.String()
+}
+
+// zshCompletion renders the completion script for zsh, as a call to
+// the _arguments function.
+func (s *Spec) zshCompletion(prog string) string {
+	excludes := make(map[string][]string)
+	exclude := func(set []string) {
+		for _, name := range set {
+			for _, other := range set {
+				excludes[name] = append(excludes[name], other)
+			}
+		}
+	}
+	for _, set := range s.exclusive {
+		exclude(set)
+	}
+	for neg, opt := range s.negated {
+		exclude([]string{opt, neg})
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n_arguments -s -S", prog)
+	for _, opt := range s.completed() {
+		name := opt.Long
+		if name == "" {
+			name = opt.Short
+		}
+		var names []string
+		if opt.Short != "" {
+			names = append(names, opt.Short)
+		}
+		if opt.Long != "" {
+			names = append(names, opt.Long)
+		}
+		var excluded []string
+		seen := make(map[string]bool)
+		for _, other := range append(excludes[name], name) {
+			if seen[other] {
+				continue
+			}
+			seen[other] = true
+			if short := s.shortAlias(other); short != "" {
+				excluded = append(excluded, short)
+			}
+			excluded = append(excluded, other)
+		}
+		for _, n := range names {
+			spec := n
+			if len(excluded) > 1 {
+				spec = "(" + strings.Join(excluded, " ") + ")" + spec
+			}
+			long := strings.HasPrefix(n, "--")
+			switch {
+			case opt.Optional && long:
+				spec += "=-"
+			case opt.Optional:
+				spec += "-"
+			case opt.TakesArg && long:
+				spec += "="
+			case opt.TakesArg:
+				spec += "+"
+			}
+			if opt.Description != "" {
+				spec += "[" + zshEscape(opt.Description) + "]"
+			}
+			if opt.TakesArg {
+				if opt.Optional {
+					spec += ":"
+				}
+				action := zshAction(opt)
+				if name := s.dynamic(opt); name != "" {
+					action = `{compadd -- ${(f)"$($words[1] --complete-values=` +
+						name + `=$PREFIX 2>/dev/null)"}}`
+				}
+				spec += ":" + zshMetavar(opt.Metavar) + ":" + action
+			}
+			b.WriteString(" \\\n    " + shellQuote(spec))
+		}
+	}
+	operands := s.operands
+	if operands == "" {
+		operands = "ARG"
+	}
+	b.WriteString(" \\\n    " + shellQuote("*:"+zshEscape(operands)+":_files") + "\n")
+	return b.String()
+}
+
+// shortAlias returns the short alias of the long option, if any.
+func (s *Spec) shortAlias(name string) string {
+	for short, long := range s.aliases {
+		if long == name {
+			return short
+		}
+	}
+	return ""
+}
+
+// zshMetavar escapes the name of an argument for _arguments.
+func zshMetavar(metavar string) string {
+	if metavar == "" {
+		metavar = "ARG"
+	}
+	return zshEscape(metavar)
+}
+
+// zshAction returns the action completing the argument of opt, for
+// _arguments.
+func zshAction(opt OptionSpec) string {
+	values, files := completionValues(opt)
+	switch {
+	case files:
+		return "_files"
+	case values != nil:
+		escaped := make([]string, len(values))
+		for i, value := range values {
+			escaped[i] = strings.NewReplacer(
+				`\`, `\\`, " ", `\ `, "(", `\(`, ")", `\)`, ":", `\:`,
+			).Replace(value)
+		}
+		return "(" + strings.Join(escaped, " ") + ")"
+	}
+	return " "
+}
+
+// zshEscape escapes text for a description (or a message) in a spec
+// for _arguments.
+func zshEscape(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`,
+	).Replace(text)
+}
+
+// fishCompletion renders the completion script for fish, as a
+// "complete" command for each option.
+func (s *Spec) fishCompletion(prog string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n", prog)
+	for _, opt := range s.completed() {
+		args := []string{"complete", "-c", fishQuote(prog)}
+		if opt.Short != "" {
+			args = append(args, "-s", fishQuote(opt.Short[1:]))
+		}
+		if opt.Long != "" {
+			args = append(args, "-l", fishQuote(opt.Long[2:]))
+		}
+		if opt.TakesArg {
+			values, files := completionValues(opt)
+			dynamic := s.dynamic(opt)
+			if dynamic != "" {
+				values, files = []string{}, false
+			}
+			switch {
+			case opt.Optional && values != nil:
+				args = append(args, "-f")
+			case !opt.Optional && files:
+				args = append(args, "-r", "-F")
+			case !opt.Optional:
+				args = append(args, "-x")
+			}
+			if dynamic != "" {
+				args = append(args, "-a", fishQuote("("+fishQuote(prog)+
+					" --complete-values="+dynamic+"=(commandline -ct))"))
+			} else if values != nil {
+				quoted := make([]string, len(values))
+				for i, value := range values {
+					quoted[i] = fishQuote(value)
+				}
+				args = append(args, "-a", fishQuote(strings.Join(quoted, " ")))
+			}
+		}
+		if opt.Description != "" {
+			args = append(args, "-d", fishQuote(opt.Description))
+		}
+		b.WriteString(strings.Join(args, " ") + "\n")
+	}
+	return b.String()
+}
+
+// fishQuote quotes text for fish, unless it is made of safe
+// characters only.
+func fishQuote(text string) string {
+	if text != "" && strings.Trim(text, "abcdefghijklmnopqrstuvwxyz"+
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,/=+@%") == "" {
+		return text
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(text) + "'"
+}
+
+// powershellCompletion renders the completion script for PowerShell,
+// as a call to Register-ArgumentCompleter. PowerShell completes file
+// names by itself, whenever the script offers nothing. The names are
+// compared case-sensitively, unlike PowerShell's own.
+func (s *Spec) powershellCompletion(prog string) string {
+	var options, takesArg, values, dynamic []string
+	for _, opt := range s.completed() {
+		text := opt.Description
+		for _, name := range []string{opt.Short, opt.Long} {
+			if name == "" {
+				continue
+			}
+			if opt.Description == "" {
+				text = name
+			}
+			options = append(options, fmt.Sprintf(
+				"        ,@(%s, %s)\n", powershellQuote(name), powershellQuote(text)))
+			if opt.TakesArg && !opt.Optional {
+				takesArg = append(takesArg, powershellQuote(name))
+			}
+			if s.dynamic(opt) != "" {
+				dynamic = append(dynamic, powershellQuote(name))
+			} else if list, _ := completionValues(opt); list != nil {
+				quoted := make([]string, len(list))
+				for i, value := range list {
+					quoted[i] = powershellQuote(value)
+				}
+				values = append(values, fmt.Sprintf("    $values[%s] = @(%s)\n",
+					powershellQuote(name), strings.Join(quoted, ", ")))
+			}
+		}
+	}
+	return "# PowerShell completion for " + prog + "\n" +
+		"Register-ArgumentCompleter -Native -CommandName " + powershellQuote(prog) +
+		" -ScriptBlock {\n" +
+		"    param($wordToComplete, $commandAst, $cursorPosition)\n" +
+		"    $options = @(\n" + strings.Join(options, "") + "    )\n" +
+		"    $takesArg = @(" + strings.Join(takesArg, ", ") + ")\n" +
+		"    $values = [Collections.Hashtable]::new([StringComparer]::Ordinal)\n" +
+		strings.Join(values, "") +
+		"    $dynamic = @(" + strings.Join(dynamic, ", ") + ")\n" +
+		`    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.EndOffset -lt $cursorPosition } |
+        ForEach-Object { $_.ToString() })
+    $prev = if ($words.Count -gt 1) { $words[-1] } else { '' }
+    $prefix = ''
+    $name = ''
+    if ($wordToComplete -match '^(--[^=]+=)(.*)$') {
+        $prefix = $Matches[1]
+        $name = $prefix.TrimEnd('=')
+        $wordToComplete = $Matches[2]
+    } elseif ($takesArg -ccontains $prev) {
+        $name = $prev
+    }
+    if ($name) {
+        $candidates = $values[$name]
+        if ($dynamic -ccontains $name) {
+            $command = $commandAst.CommandElements[0].ToString()
+            $candidates = & $command "--complete-values=$name=$wordToComplete" 2>$null
+        }
+        foreach ($value in $candidates) {
+            if ($value.StartsWith($wordToComplete)) {
+                [System.Management.Automation.CompletionResult]::new(
+                    $prefix + $value, $value, 'ParameterValue', $value)
+            }
+        }
+        return
+    }
+    if ($wordToComplete.StartsWith('-')) {
+        foreach ($option in $options) {
+            if ($option[0].StartsWith($wordToComplete)) {
+                [System.Management.Automation.CompletionResult]::new(
+                    $option[0], $option[0], 'ParameterName', $option[1])
+            }
+        }
+    }
+}
+`
+}
+
+// powershellQuote quotes text for PowerShell.
+func powershellQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+// identifier makes name usable as (a part of) the name of a shell
+// function, by replacing anything but ASCII letters and digits with
+// underscores.
+func identifier(name string) string {
+	return strings.Map(func(c rune) rune {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			return c
+		}
+		return '_'
+	}, name)
+}
+
+// shellQuote quotes text for a POSIX shell.
+func shellQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
@@ -0,0 +1,272 @@
+package getopt
+
+import "testing"
+import "errors"
+import "reflect"
+import "strings"
+
+func Test_Completion_words(t *testing.T) {
+	p, err := NewParser(nil, "vx:", []string{"help|h", "output="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"--help", "--output"}
+	if words := p.CompletionWords(false); !reflect.DeepEqual(words, expected) {
+		t.Log("got", words)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong words")
+	}
+	expected = []string{"--help", "--output", "-v", "-x", "-h"}
+	if words := p.CompletionWords(true); !reflect.DeepEqual(words, expected) {
+		t.Log("got", words)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong words")
+	}
+}
+
+func Test_Spec_PrintCompletion_bash(t *testing.T) {
+	spec, err := Compile("vn:", []string{"output|o=", "color=?", "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.WithValues("--color", "always", "never").ValidInt("-n").Hidden("--debug")
+	var b strings.Builder
+	if err := spec.PrintCompletion(&b, "prog", "bash"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# bash completion for prog
+_prog_completion() {
+    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]} eq=
+    if [[ $cur == = ]]; then
+        cur= eq='='
+    elif [[ $prev == = && $COMP_CWORD -gt 1 ]]; then
+        prev=${COMP_WORDS[COMP_CWORD-2]} eq='='
+    fi
+    case $eq$prev in
+        -n)
+            COMPREPLY=()
+            return
+            ;;
+        -o|--output|=--output)
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return
+            ;;
+        =--color)
+            COMPREPLY=($(compgen -W 'always never' -- "$cur"))
+            return
+            ;;
+    esac
+    if [[ $cur == -* ]]; then
+        COMPREPLY=($(compgen -W '-v -n -o --output --color' -- "$cur"))
+        return
+    fi
+    COMPREPLY=($(compgen -f -- "$cur"))
+}
+complete -F _prog_completion 'prog'
+`
+	if b.String() != expected {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong script")
+	}
+
+	err = spec.PrintCompletion(&b, "prog", "csh")
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Log("got", err)
+		t.Fatal("expected an unsupported shell")
+	}
+}
+
+func Test_Spec_CompletionHook(t *testing.T) {
+	spec, err := Compile("v", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.CompletionHook("prog")
+	var b strings.Builder
+	spec.Stdout = &b
+	_, err = spec.ParseResult([]string{"-v", "--generate-completion=bash"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrCompletion) ||
+		!strings.HasPrefix(b.String(), "# bash completion for prog\n") {
+		t.Log("got", err, b.String())
+		t.Fatal("expected the completion script")
+	}
+	if strings.Contains(b.String(), "--generate-completion") {
+		t.Fatal("expected the hook to be hidden")
+	}
+	_, err = spec.ParseResult([]string{"--generate-completion=csh"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Log("got", err)
+		t.Fatal("expected an unsupported shell")
+	}
+}
+
+func Test_Spec_PrintCompletion_zsh(t *testing.T) {
+	spec, err := Compile("vqC::n:", []string{"output|o=", "color=?", "debug", "sync"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Description("-v", "explain what is done").
+		Description("--output", "write to [FILE]: or else").
+		Metavar("-o", "FILE").
+		WithValues("--color", "always", "never").
+		ValidInt("-n").Hidden("--debug").Negatable("--sync").
+		Exclusive("-v", "-q").
+		Operands("FILE")
+	var b strings.Builder
+	if err := spec.PrintCompletion(&b, "prog", "zsh"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `#compdef prog
+
+_arguments -s -S \
+    '(-v -q)-v[explain what is done]' \
+    '(-v -q)-q' \
+    '-C-::ARG:_files' \
+    '-n+:ARG: ' \
+    '(-o --output)-o+[write to \[FILE\]\: or else]:FILE:_files' \
+    '(-o --output)--output=[write to \[FILE\]\: or else]:FILE:_files' \
+    '--color=-::ARG:(always never)' \
+    '(--sync --no-sync)--sync' \
+    '(--sync --no-sync)--no-sync' \
+    '*:FILE:_files'
+`
+	if b.String() != expected {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong script")
+	}
+}
+
+func Test_Spec_PrintCompletion_fish(t *testing.T) {
+	spec, err := Compile("vC::n:", []string{"output|o=", "color=?", "debug", "sync"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Description("-v", "explain what's done").
+		Description("--output", "write to FILE").
+		WithValues("--color", "always", "never", "if tty").
+		ValidInt("-n").Hidden("--debug").Negatable("--sync")
+	var b strings.Builder
+	if err := spec.PrintCompletion(&b, "prog", "fish"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# fish completion for prog
+complete -c prog -s v -d 'explain what\'s done'
+complete -c prog -s C
+complete -c prog -s n -x
+complete -c prog -s o -l output -r -F -d 'write to FILE'
+complete -c prog -l color -f -a 'always never \'if tty\''
+complete -c prog -l sync
+complete -c prog -l no-sync
+`
+	if b.String() != expected {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong script")
+	}
+}
+
+func Test_Spec_PrintCompletion_powershell(t *testing.T) {
+	spec, err := Compile("vVn:", []string{"color=?", "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Description("-v", "explain what's done").
+		WithValues("--color", "always", "never").
+		WithValues("-n", "1", "2").Hidden("--debug")
+	var b strings.Builder
+	if err := spec.PrintCompletion(&b, "prog", "powershell"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# PowerShell completion for prog
+Register-ArgumentCompleter -Native -CommandName 'prog' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $options = @(
+        ,@('-v', 'explain what''s done')
+        ,@('-V', '-V')
+        ,@('-n', '-n')
+        ,@('--color', '--color')
+    )
+    $takesArg = @('-n')
+    $values = [Collections.Hashtable]::new([StringComparer]::Ordinal)
+    $values['-n'] = @('1', '2')
+    $values['--color'] = @('always', 'never')
+`
+	if !strings.HasPrefix(b.String(), expected) {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong script")
+	}
+}
+
+func Test_Spec_Complete(t *testing.T) {
+	spec, err := Compile("v", []string{"branch|b=", "host="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Required("--host").Complete("--branch", func(prefix string) []string {
+		var branches []string
+		for _, branch := range []string{"main", "fix/a", "fix/b"} {
+			if strings.HasPrefix(branch, prefix) {
+				branches = append(branches, branch)
+			}
+		}
+		return branches
+	})
+	for _, arg := range []string{"--branch=fix/", "-b=fix/"} {
+		var b strings.Builder
+		spec.Stdout = &b
+		_, err = spec.ParseResult([]string{"--complete-values=" + arg})
+		errorQA(t, err)
+		if !errors.Is(err, ErrCompletion) || b.String() != "fix/a\nfix/b\n" {
+			t.Log("input", arg)
+			t.Log("got", err, b.String())
+			t.Fatal("recieved wrong candidates")
+		}
+	}
+
+	var b strings.Builder
+	if err := spec.PrintCompletion(&b, "prog", "bash"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `mapfile -t COMPREPLY < <("${COMP_WORDS[0]}" `+
+		`--complete-values=--branch="$cur" 2>/dev/null)`) ||
+		strings.Contains(b.String(), "--complete-values ") {
+		t.Log("got", b.String())
+		t.Fatal("expected the script to call back for --branch")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func Test_Spec_CompletionHook_writeError(t *testing.T) {
+	spec, err := Compile("v", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.CompletionHook("prog")
+	spec.Stdout = failingWriter{}
+	spec.CollectErrors = true
+	args := []string{"-v", "--generate-completion=bash"}
+	_, err = spec.ParseResult(args)
+	errorQA(t, err)
+	var eparse *ParseError
+	if !errors.As(err, &eparse) || eparse.Err == nil ||
+		eparse.Err.Error() != "broken pipe" || !errors.Is(err, ErrCompletion) {
+		t.Log("got", err)
+		t.Fatal("expected the write error")
+	}
+	_, err = spec.ParseTokens(args)
+	if !errors.As(err, &eparse) || eparse.Err == nil {
+		t.Log("got", err)
+		t.Fatal("expected the write error")
+	}
+}
@@ -0,0 +1,95 @@
+// Package configfile loads the values of options from a configuration
+// file, beneath the values given on the command line.
+//
+// The file is a JSON object, keyed by the names of the long options
+// without the leading dashes:
+//
+//	{
+//	    "output": "out.txt",
+//	    "level": 3,
+//	    "verbose": true
+//	}
+//
+// The values are set with getopt.Spec.ConfigValue, so that the
+// command line takes precedence, followed by the environment (see
+// getopt.Spec.Env), then the file, and then the defaults (see
+// getopt.Spec.Default), which help texts keep showing. They are
+// returned by getopt.Result.Lookup, and stored in the destinations
+// bound to the options, such as with Spec.StringVar.
+//
+// Only JSON is supported, as it is the only such format in the
+// standard library.
+package configfile
+
+import "encoding/json"
+import "fmt"
+import "io"
+import "os"
+import "sort"
+import "strconv"
+
+import "github.com/rollcat/getopt"
+
+// Load reads the configuration file at path into spec; see Apply.
+func Load(spec *getopt.Spec, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := Apply(spec, f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Apply reads a JSON object from r, and sets its values as those of
+// the long options named by its keys (see getopt.Spec.ConfigValue). A
+// value may be a string, a number, or a boolean, which are converted
+// to the argument of the option as it would be written on the command
+// line; a null value is skipped. A key which does not name a declared
+// long option, or a value of any other type, is an error, and leaves
+// spec alone.
+func Apply(spec *getopt.Spec, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var values map[string]interface{}
+	if err := dec.Decode(&values); err != nil {
+		return err
+	}
+	declared := make(map[string]bool)
+	for _, opt := range spec.NewParser(nil).Describe() {
+		if opt.Long != "" {
+			declared[opt.Long] = true
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	configured := make(map[string]string)
+	for _, key := range keys {
+		name := "--" + key
+		if !declared[name] {
+			return fmt.Errorf("%q is not a long option", key)
+		}
+		switch value := values[key].(type) {
+		case nil:
+		case string:
+			configured[name] = value
+		case json.Number:
+			configured[name] = value.String()
+		case bool:
+			configured[name] = strconv.FormatBool(value)
+		default:
+			return fmt.Errorf("%q has a value of an unsupported type", key)
+		}
+	}
+	for _, key := range keys {
+		if value, has := configured["--"+key]; has {
+			spec.ConfigValue("--"+key, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package configfile
+
+import "testing"
+import "os"
+import "path/filepath"
+import "strings"
+
+import "github.com/rollcat/getopt"
+
+func Test_Apply(t *testing.T) {
+	spec, err := getopt.Compile("v", []string{
+		"output|o=", "level=", "verbose", "ratio=", "unset=",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var level int
+	var verbose bool
+	spec.IntVar(&level, "--level").BoolVar(&verbose, "--verbose").
+		Default("--level", "1")
+	err = Apply(spec, strings.NewReader(`{
+		"output": "file.txt",
+		"level": 3,
+		"verbose": true,
+		"ratio": 1.5,
+		"unset": null
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := spec.ParseResult([]string{"-o", "argv.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level != 3 || !verbose {
+		t.Log("got", level, verbose)
+		t.Fatal("recieved wrong variables")
+	}
+	for name, expected := range map[string]struct {
+		value string
+		src   getopt.Source
+	}{
+		"--output": {"argv.txt", getopt.CommandLine},
+		"--level":  {"3", getopt.ConfigFile},
+		"--ratio":  {"1.5", getopt.ConfigFile},
+		"--unset":  {"", getopt.Unset},
+	} {
+		if value, src := r.Lookup(name); value != expected.value || src != expected.src {
+			t.Log("option", name)
+			t.Log("got", value, src)
+			t.Fatal("recieved wrong value")
+		}
+	}
+	for _, opt := range spec.NewParser(nil).Describe() {
+		if opt.Long == "--level" && opt.Default != "1" {
+			t.Log("got", opt.Default)
+			t.Fatal("expected the default to be left alone")
+		}
+	}
+}
+
+func Test_Apply_errors(t *testing.T) {
+	for _, input := range []string{
+		`{"wizard": "yes"}`,
+		`{"v": true}`,
+		`{"output": ["a", "b"]}`,
+		`{"output": "a", "level": {}}`,
+		`["output"]`,
+		`{`,
+	} {
+		spec, err := getopt.Compile("v", []string{"output=", "level="})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Apply(spec, strings.NewReader(input)); err == nil {
+			t.Log("input", input)
+			t.Fatal("expected an error")
+		}
+		r, err := spec.ParseResult(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, src := r.Lookup("--output"); src != getopt.Unset {
+			t.Log("input", input)
+			t.Fatal("expected the spec to be left alone")
+		}
+	}
+}
+
+func Test_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"output": "x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := getopt.Compile("", []string{"output="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(spec, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(spec, path+".missing"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
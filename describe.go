@@ -0,0 +1,86 @@
+package getopt
+
+// OptionSpec describes a declared option, as returned by Describe.
+type OptionSpec struct {
+	// Short and Long are the names of the option, such as "-o" and
+	// "--output". Either may be empty; both are set for a long
+	// option with a short alias.
+	Short string `json:"short,omitempty"`
+	Long  string `json:"long,omitempty"`
+
+	// TakesArg tells whether the option takes an argument, and
+	// Optional whether the argument may be omitted.
+	TakesArg bool `json:"takesArg"`
+	Optional bool `json:"optional"`
+
+	// Default, Description and Group (the section) are as set with
+	// the Spec methods of the same names; Hidden is set with
+	// Spec.Hidden.
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+	Group       string `json:"group,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty"`
+
+	// Values are the allowed arguments, as set with
+	// Spec.WithValues, or nil if any argument is allowed.
+	Values []string `json:"values,omitempty"`
+
+	// Metavar names the argument, as set with Spec.Metavar, or is
+	// empty if not set.
+	Metavar string `json:"metavar,omitempty"`
+
+	// Env is the environment variable set with Spec.Env or
+	// Spec.EnvPrefix, if any.
+	Env string `json:"env,omitempty"`
+
+	// Type is the type of the argument, as checked by one of
+	// Spec.ValidInt, ValidUint, ValidFloat, ValidBool or
+	// ValidDuration ("int", "uint", "float", "bool" or "duration"),
+	// or as stored by the variable bound to the option (see
+	// Spec.StringVar and the like), which may also be "string". It
+	// is empty if not known.
+	Type string `json:"type,omitempty"`
+}
+
+// Describe lists all the declared options, with everything known
+// about them, for tools such as documentation generators or
+// wrappers. The short options come first, followed by the long
+// options (along with their aliases), each in the order of their
+// declaration.
+func (p *Parser) Describe() []OptionSpec {
+	return p.spec.describe()
+}
+
+func (s *Spec) describe() []OptionSpec {
+	alias := make(map[string]string)
+	for short, long := range s.aliases {
+		alias[long] = short
+	}
+	var specs []OptionSpec
+	for _, name := range s.shortNames {
+		if _, has := s.aliases[name]; !has {
+			specs = append(specs, s.optionSpec(name, "", name, s.shorts[name]))
+		}
+	}
+	for _, name := range s.longNames {
+		specs = append(specs, s.optionSpec(alias[name], name, name, s.longs[name]))
+	}
+	return specs
+}
+
+func (s *Spec) optionSpec(short, long, name string, ar arity) OptionSpec {
+	return OptionSpec{
+		Short:       short,
+		Long:        long,
+		TakesArg:    ar != argNone,
+		Optional:    ar == argOptional,
+		Default:     s.defaults[name],
+		Description: s.descriptions[name],
+		Group:       s.sections[name],
+		Hidden:      s.hidden[name],
+		Values:      s.values[name],
+		Metavar:     s.metavars[name],
+		Env:         s.env[name],
+		Type:        s.types[name],
+	}
+}
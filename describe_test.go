@@ -0,0 +1,28 @@
+package getopt
+
+import "testing"
+import "reflect"
+
+func Test_Describe(t *testing.T) {
+	spec, err := Compile("vC::", []string{"output|o=", "color=?", "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Description("--output", "write to FILE").Default("-o", "a.out")
+	spec.Section("Output control", "--output", "--color")
+	spec.Hidden("--debug")
+	expected := []OptionSpec{
+		{Short: "-v"},
+		{Short: "-C", TakesArg: true, Optional: true},
+		{Short: "-o", Long: "--output", TakesArg: true, Default: "a.out",
+			Description: "write to FILE", Group: "Output control"},
+		{Long: "--color", TakesArg: true, Optional: true,
+			Group: "Output control"},
+		{Long: "--debug", Hidden: true},
+	}
+	if specs := spec.NewParser(nil).Describe(); !reflect.DeepEqual(specs, expected) {
+		t.Log("got", specs)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong description")
+	}
+}
@@ -0,0 +1,119 @@
+package getopt
+
+import "fmt"
+import "os"
+import "strings"
+
+// Source tells where the value of an option came from, as returned by
+// Result.Lookup.
+type Source int
+
+const (
+	// Unset means the option was not given, and has no other value.
+	Unset Source = iota
+
+	// CommandLine means the option was given in the argument list.
+	CommandLine
+
+	// Environment means the value came from the environment
+	// variable set with Spec.Env.
+	Environment
+
+	// ConfigFile means the value came from a configuration file, as
+	// set with Spec.ConfigValue.
+	ConfigFile
+
+	// Default means the value is the one set with Spec.Default.
+	Default
+)
+
+func (src Source) String() string {
+	switch src {
+	case CommandLine:
+		return "command line"
+	case Environment:
+		return "environment"
+	case ConfigFile:
+		return "config file"
+	case Default:
+		return "default"
+	}
+	return "unset"
+}
+
+// Lookup returns the effective value of the named option, and where
+// it came from: the argument list (the last occurrence wins, same as
+// with Get), or failing that the environment variable set with
+// Spec.Env, or failing that the value read from a configuration file
+// (see Spec.ConfigValue), or failing that the default set with
+// Spec.Default. The value of an option given without an argument is
+// "", and that of an option whose negation (see Spec.Negatable) was
+// given last is "false"; either way, the other sources are ignored.
+//
+// The option can be named by its alias; naming an undeclared option
+// panics.
+func (r *Result) Lookup(name string) (string, Source) {
+	name, _ = r.spec.lookup(name)
+	if r.negatedLast(name) {
+		return "false", CommandLine
+	}
+	if arg, has := r.Get(name); has {
+		return arg, CommandLine
+	}
+	if variable, has := r.spec.env[name]; has {
+		if value, has := os.LookupEnv(variable); has {
+			return value, Environment
+		}
+	}
+	if value, has := r.spec.configured[name]; has {
+		return value, ConfigFile
+	}
+	if value, has := r.spec.defaults[name]; has {
+		return value, Default
+	}
+	return "", Unset
+}
+
+// negatedLast reports whether the negation of the named option was
+// given, after any occurrence of the option itself.
+func (r *Result) negatedLast(name string) bool {
+	if !strings.HasPrefix(name, "--") ||
+		r.spec.negated["--no-"+name[2:]] != name {
+		return false
+	}
+	on, given := r.Flag(name)
+	return given && !on
+}
+
+// Effective renders the effective values of all the declared options
+// (as returned by Lookup) along with where they came from, one option
+// per line, in the order of Describe; as in:
+//
+//	--output=out.txt (command line)
+//	--color=auto (environment: PROG_COLOR)
+//	--width=80 (config file)
+//	--level=1 (default)
+//	--verbose (unset)
+//
+// This is meant for debugging, e.g. for a "--show-config" option.
+func (r *Result) Effective() string {
+	var b strings.Builder
+	for _, spec := range r.spec.describe() {
+		name := spec.Long
+		if name == "" {
+			name = spec.Short
+		}
+		value, src := r.Lookup(name)
+		b.WriteString(name)
+		if value != "" || src != Unset && src != CommandLine ||
+			src == CommandLine && r.GivenWithValue(name) {
+			b.WriteString("=" + value)
+		}
+		label := src.String()
+		if src == Environment {
+			label += ": " + r.spec.env[name]
+		}
+		fmt.Fprintf(&b, " (%s)\n", label)
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package getopt
+
+import "testing"
+import "os"
+import "reflect"
+
+func Test_Result_Effective(t *testing.T) {
+	spec, err := Compile("v", []string{"output|o=", "color=?", "width=", "level=", "name="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Env("--color", "GETOPT_TEST_COLOR").
+		Env("--level", "GETOPT_TEST_LEVEL").
+		Default("--level", "1").
+		Default("--name", "fizzy").
+		Default("--width", "72").ConfigValue("--width", "80")
+	os.Setenv("GETOPT_TEST_COLOR", "auto")
+	defer os.Unsetenv("GETOPT_TEST_COLOR")
+	os.Unsetenv("GETOPT_TEST_LEVEL")
+
+	r, err := spec.ParseResult([]string{"-o", "out.txt", "--name", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "" +
+		"-v (unset)\n" +
+		"--output=out.txt (command line)\n" +
+		"--color=auto (environment: GETOPT_TEST_COLOR)\n" +
+		"--width=80 (config file)\n" +
+		"--level=1 (default)\n" +
+		"--name= (command line)\n"
+	if got := r.Effective(); got != expected {
+		t.Log("got", got)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong effective configuration")
+	}
+
+	r, err = spec.ParseResult([]string{"-v", "--color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, src := r.Lookup("-v"); value != "" || src != CommandLine {
+		t.Log("got", value, src)
+		t.Fatal("expected -v from the command line")
+	}
+	if value, src := r.Lookup("--color"); value != "" || src != CommandLine {
+		t.Log("got", value, src)
+		t.Fatal("expected the command line to take precedence")
+	}
+	if value, src := r.Lookup("-o"); value != "" || src != Unset {
+		t.Log("got", value, src)
+		t.Fatal("expected --output to be unset")
+	}
+}
+
+func Test_Spec_EnvPrefix(t *testing.T) {
+	spec, err := Compile("q", []string{"dry-run", "output|o=", "level="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dryRun bool
+	var output string
+	spec.Env("--level", "GETOPT_TEST_LVL").EnvPrefix("GETOPT_TEST").
+		BoolVar(&dryRun, "--dry-run").StringVar(&output, "-o").
+		Default("-o", "-")
+	if !reflect.DeepEqual(spec.env, map[string]string{
+		"--dry-run": "GETOPT_TEST_DRY_RUN",
+		"--output":  "GETOPT_TEST_OUTPUT",
+		"--level":   "GETOPT_TEST_LVL",
+	}) {
+		t.Log("got", spec.env)
+		t.Fatal("recieved wrong variables")
+	}
+	os.Setenv("GETOPT_TEST_DRY_RUN", "1")
+	os.Setenv("GETOPT_TEST_OUTPUT", "env.txt")
+	defer os.Unsetenv("GETOPT_TEST_DRY_RUN")
+	defer os.Unsetenv("GETOPT_TEST_OUTPUT")
+
+	r, err := spec.ParseResult(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dryRun || output != "env.txt" {
+		t.Log("got", dryRun, output)
+		t.Fatal("expected the environment to be used")
+	}
+	if value, src := r.Lookup("-o"); value != "env.txt" || src != Environment {
+		t.Log("got", value, src)
+		t.Fatal("recieved wrong source")
+	}
+
+	_, err = spec.ParseResult([]string{"-o", "argv.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "argv.txt" {
+		t.Log("got", output)
+		t.Fatal("expected the command line to take precedence")
+	}
+}
+
+func Test_Spec_EnvPrefix_negated(t *testing.T) {
+	spec, err := Compile("", []string{"color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var color bool
+	spec.Negatable("--color").EnvPrefix("GETOPT_TEST").BoolVar(&color, "--color")
+	t.Setenv("GETOPT_TEST_COLOR", "1")
+
+	r, err := spec.ParseResult([]string{"--no-color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if color {
+		t.Fatal("expected the command line to take precedence")
+	}
+	if value, src := r.Lookup("--color"); value != "false" || src != CommandLine {
+		t.Log("got", value, src)
+		t.Fatal("recieved wrong source")
+	}
+
+	_, err = spec.ParseResult(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !color {
+		t.Fatal("expected the environment to be used")
+	}
+}
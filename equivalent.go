@@ -0,0 +1,76 @@
+package getopt
+
+import "reflect"
+import "sort"
+
+// Equivalent reports whether the argument lists a and b parse to the
+// same options, in the same order, and the same leftovers; e.g. "-ab"
+// and "-a -b" are equivalent, and so are "-ofile" and "-o file". This
+// is meant for testing programs, to check that several spellings of
+// a command line mean the same. A ParseError from either list is
+// returned as it is.
+//
+// The positions of the options in the lists (OptArg.Index) are not
+// compared. See Spec.Equivalent for comparing the options regardless
+// of their order.
+func Equivalent(a, b []string, shortopts string, longopts []string) (
+	bool,
+	error,
+) {
+	spec, err := Compile(shortopts, longopts)
+	if err != nil {
+		return false, err
+	}
+	return spec.Equivalent(a, b, false)
+}
+
+// Equivalent works like the Equivalent function, but parses a and b
+// according to the compiled Spec (including its Config). If anyOrder
+// is set, the options may be given in any order; note that for
+// options which are only meant to be given once, the last occurrence
+// usually wins, which this disregards.
+func (s *Spec) Equivalent(a, b []string, anyOrder bool) (bool, error) {
+	ra, err := s.ParseResult(a)
+	if err != nil {
+		return false, err
+	}
+	rb, err := s.ParseResult(b)
+	if err != nil {
+		return false, err
+	}
+	oa, ob := canonical(ra.Options, anyOrder), canonical(rb.Options, anyOrder)
+	return reflect.DeepEqual(oa, ob) && sameArgs(ra.Args(), rb.Args()), nil
+}
+
+// sameArgs reports whether the argument lists hold the same arguments;
+// unlike with reflect.DeepEqual, nil and an empty list are the same.
+func sameArgs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// canonical copies the options without their positions, and sorts
+// them, if their order does not matter.
+func canonical(opts Options, anyOrder bool) Options {
+	canon := make(Options, len(opts))
+	for i, o := range opts {
+		o.Index = 0
+		canon[i] = o
+	}
+	if anyOrder {
+		sort.SliceStable(canon, func(i, j int) bool {
+			if canon[i].Option != canon[j].Option {
+				return canon[i].Option < canon[j].Option
+			}
+			return canon[i].Argument < canon[j].Argument
+		})
+	}
+	return canon
+}
@@ -0,0 +1,51 @@
+package getopt
+
+import "testing"
+
+func Test_Equivalent(t *testing.T) {
+	shortopts, longopts := "abo:", []string{"output|O="}
+	for _, tc := range []struct {
+		a, b     []string
+		anyOrder bool
+		expected bool
+	}{
+		{[]string{"-ab"}, []string{"-a", "-b"}, false, true},
+		{[]string{"-ab"}, []string{"-ba"}, false, false},
+		{[]string{"-ab"}, []string{"-ba"}, true, true},
+		{[]string{"-ofile", "x"}, []string{"-o", "file", "x"}, false, true},
+		{[]string{"-Ofile"}, []string{"--output=file"}, false, true},
+		{[]string{"-a", "x"}, []string{"-a", "y"}, false, false},
+		{[]string{"-a", "x", "-b"}, []string{"-ab", "x"}, true, false},
+		{[]string{"-ofile"}, []string{"-o", "other"}, true, false},
+		{nil, []string{}, false, true},
+		{[]string{"-a"}, []string{"-a", ""}, false, false},
+	} {
+		spec, err := Compile(shortopts, longopts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := spec.Equivalent(tc.a, tc.b, tc.anyOrder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.expected {
+			t.Log("input", tc.a, tc.b, tc.anyOrder)
+			t.Log("got", got)
+			t.Fatal("recieved wrong equivalence")
+		}
+		if !tc.anyOrder {
+			got, err := Equivalent(tc.a, tc.b, shortopts, longopts)
+			if err != nil || got != tc.expected {
+				t.Log("input", tc.a, tc.b)
+				t.Log("got", got, err)
+				t.Fatal("recieved wrong equivalence")
+			}
+		}
+	}
+
+	_, err := Equivalent([]string{"-a"}, []string{"-x"}, shortopts, longopts)
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -14,9 +14,8 @@ import (
 )
 
 func main() {
-	_, opts, err := getopt.GetOpt(
-		os.Args[1:],
-		"aAbBcCdDfF:gGhHI:klLmnNopqQrRsStT:uUvw:xXZ1",
+	spec, err := getopt.Compile(
+		"aAbBcCdDfFgGhHI:klLmnNopqQrRsStT:uUvw:xXZ1",
 		[]string{
 			"all",        // -a
 			"almost-all", // -A
@@ -26,11 +25,11 @@ func main() {
 			"ignore-backups", // -B
 			// -c
 			// -C
-			"color=",    // TODO: optional args
+			"color=?",
 			"directory", // -d
 			"dired",     // -D
 			// -f
-			"classify=", // -F // TODO: optional args
+			"classify=?", // -F
 			"file-type",
 			"format=",
 			"full-time",
@@ -42,7 +41,7 @@ func main() {
 			"dereference-command-line", // -H
 			"dereference-command-line-symlink-to-dir",
 			"hide=",
-			"hyperlink=", // TODO: optional args
+			"hyperlink=?",
 			"indicator-style=",
 			"inode",     // -i
 			"ignore=",   // -I
@@ -80,7 +79,27 @@ func main() {
 			"version",
 		},
 	)
+	if err != nil {
+		panic(err)
+	}
+	when := []string{"always", "yes", "force", "never", "no", "none",
+		"auto", "tty", "if-tty"}
+	spec.WithValues("--color", when...).
+		WithValues("--classify", when...).
+		WithValues("--hyperlink", when...).
+		WithValues("--format", "across", "commas", "horizontal", "long",
+			"single-column", "verbose", "vertical").
+		WithValues("--indicator-style", "none", "slash", "file-type",
+			"classify").
+		WithValues("--quoting-style", "literal", "locale", "shell",
+			"shell-always", "shell-escape", "shell-escape-always", "c",
+			"escape").
+		WithValues("--sort", "none", "size", "time", "version",
+			"extension", "width").
+		WithValues("--time", "atime", "access", "use", "ctime", "status",
+			"birth", "creation", "mtime", "modification")
 
+	_, opts, err := spec.Parse(os.Args[1:])
 	if err != nil {
 		fmt.Printf("error: %s\n", err)
 		fmt.Printf("error: %#v\n", err)
@@ -7,37 +7,26 @@ import (
 	"github.com/rollcat/getopt"
 )
 
-func usage() {
-	fmt.Fprintf(os.Stderr, "Usage: %s [-h]\n", os.Args[0])
-}
-
-func help() {
-	usage()
-	fmt.Fprintf(os.Stderr,
-		`CHANGEME: This is a template for a Go commandline program.
-Options:
-    -h, --help  Show this help and exit
-`)
-}
-
 func main() {
-	args, opts, err := getopt.GetOpt(
-		os.Args[1:],
-		"h",
-		[]string{"help"},
-	)
+	spec, err := getopt.Compile("", []string{"help|h"})
+	if err != nil {
+		panic(err)
+	}
+	spec.Description("--help", "show this help and exit")
+
+	args, opts, err := spec.Parse(os.Args[1:])
 	if err != nil || len(args) != 0 {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
-		usage()
+		fmt.Fprintln(os.Stderr, spec.Usage(os.Args[0]))
 		os.Exit(1)
 	}
 
 	for _, opt := range opts {
 		switch opt.Opt() {
-		case "-h":
-			fallthrough
 		case "--help":
-			help()
+			fmt.Fprintln(os.Stderr,
+				"CHANGEME: This is a template for a Go commandline program.")
+			spec.PrintHelp(os.Stderr, os.Args[0])
 			os.Exit(0)
 		default:
 			panic("unexpected argument")
@@ -0,0 +1,95 @@
+package getopt
+
+import "strings"
+
+// FormatOptions renders the options back to arguments, which parse
+// back to the same options; e.g. for passing some of them on to
+// another program. The leftover arguments are left out, and can be
+// appended after a "--" terminator, if needed.
+//
+// Each option is rendered in a canonical form: a short option with
+// an argument has it attached, as in "-ofile", unless the argument is
+// empty, in which case it's passed separately, as in "-o", "". A long
+// option with an argument always has it attached, as in
+// "--output=file", or "--output=" for an empty one; note the latter
+// only parses back to an empty argument if the option's argument is
+// optional, or with Config.RequireEquals. An operand returned in
+// order (see InOrder) is rendered as it is.
+//
+// As FormatOptions does not know how the options were declared, it
+// assumes the defaults: a long option is rendered with "=", and an
+// empty argument of a short option with an optional argument (as
+// declared with "C::") does not parse back at all, since it can only
+// be attached. Use Spec.FormatOptions instead, where possible.
+func FormatOptions(optargs []OptArg) []string {
+	var args []string
+	for _, o := range optargs {
+		switch {
+		case o.Option == InOrder:
+			args = append(args, o.Argument)
+		case !o.HasArgument:
+			args = append(args, o.Option)
+		case strings.HasPrefix(o.Option, "--"):
+			args = append(args, o.Option+"="+o.Argument)
+		case o.Argument == "":
+			args = append(args, o.Option, "")
+		default:
+			args = append(args, o.Option+o.Argument)
+		}
+	}
+	return args
+}
+
+// FormatOptions renders the options back to arguments, same as the
+// FormatOptions function, but as declared in the Spec: a long option
+// is rendered with the Config.Assign character, and an empty argument
+// is passed separately unless the option's argument is optional (or
+// with Config.RequireEquals), so that it parses back with the Spec.
+//
+// An empty argument of a short option with an optional argument
+// cannot be rendered, and neither can an undeclared option; either
+// is a ParseError, matching ErrInvalidArgument or ErrUnknownOption.
+func (s *Spec) FormatOptions(optargs []OptArg) ([]string, error) {
+	var args []string
+	for _, o := range optargs {
+		if o.Option == InOrder {
+			args = append(args, o.Argument)
+			continue
+		}
+		ar, isLong := s.longs[o.Option]
+		if !isLong {
+			var has bool
+			if ar, has = s.shorts[o.Option]; !has {
+				return nil, &ParseError{
+					Message:       "option not declared",
+					Opt:           o.Option,
+					Unexpected:    q(o.Option),
+					notUsersFault: true,
+					kind:          ErrUnknownOption,
+				}
+			}
+		}
+		switch {
+		case !o.HasArgument:
+			args = append(args, o.Option)
+		case o.Argument == "" && isLong && ar == argRequired && !s.RequireEquals:
+			args = append(args, o.Option, "")
+		case isLong:
+			args = append(args, o.Option+s.assign()+o.Argument)
+		case o.Argument != "":
+			args = append(args, o.Option+o.Argument)
+		case ar == argRequired:
+			args = append(args, o.Option, "")
+		default:
+			return nil, &ParseError{
+				Message:       "empty optional argument cannot be formatted",
+				Opt:           o.Option,
+				Unexpected:    q(""),
+				Expected:      "a non-empty argument",
+				notUsersFault: true,
+				kind:          ErrInvalidArgument,
+			}
+		}
+	}
+	return args, nil
+}
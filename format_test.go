@@ -0,0 +1,95 @@
+package getopt
+
+import "testing"
+import "errors"
+import "reflect"
+
+func Test_FormatOptions(t *testing.T) {
+	shortopts, longopts := "vo:C::", []string{"output=", "color=?", "dry-run"}
+	input := []string{
+		"-vv", "-o-a.out", "-o", "", "-Cauto", "--output", "b.out",
+		"--color", "--color=", "--dry-run", "--", "fizzy",
+	}
+	_, optargs, err := GetOptSafe(input, shortopts, longopts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := FormatOptions(optargs)
+	expected := []string{
+		"-v", "-v", "-o-a.out", "-o", "", "-Cauto", "--output=b.out",
+		"--color", "--color=", "--dry-run",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Log("got", args)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong args")
+	}
+
+	leftovers, reparsed, err := GetOptSafe(args, shortopts, longopts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) != 0 || len(reparsed) != len(optargs) {
+		t.Log("got", leftovers, reparsed)
+		t.Fatal("expected the same options back")
+	}
+	for i := range reparsed {
+		reparsed[i].Index, optargs[i].Index = 0, 0
+	}
+	if !reflect.DeepEqual(reparsed, optargs) {
+		t.Log("got", reparsed)
+		t.Log("expected", optargs)
+		t.Fatal("expected the same options back")
+	}
+}
+
+func Test_Spec_FormatOptions(t *testing.T) {
+	spec, err := Compile("C::o:", []string{"output|O=", "color=?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Assign = ':'
+	optargs := []OptArg{
+		{Option: "-o", HasArgument: true},
+		{Option: "-C", Argument: "x", HasArgument: true},
+		{Option: "--output", Argument: "a=b", HasArgument: true},
+		{Option: "--output", HasArgument: true},
+		{Option: "--color", HasArgument: true},
+		{Option: "-C"},
+	}
+	args, err := spec.FormatOptions(optargs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"-o", "", "-Cx", "--output:a=b", "--output", "", "--color:", "-C"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Log("got", args)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong args")
+	}
+	r, err := spec.ParseResult(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range r.Options {
+		r.Options[i].Index = 0
+	}
+	if !reflect.DeepEqual(r.Options, Options(optargs)) || len(r.Args()) != 0 {
+		t.Log("got", r.Options, r.Args())
+		t.Log("expected", optargs)
+		t.Fatal("expected the same options back")
+	}
+
+	_, err = spec.FormatOptions([]OptArg{{Option: "-C", HasArgument: true}})
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Log("got", err)
+		t.Fatal("expected an empty optional argument to be rejected")
+	}
+	_, err = spec.FormatOptions([]OptArg{{Option: "-x"}})
+	errorQA(t, err)
+	if !errors.Is(err, ErrUnknownOption) {
+		t.Log("got", err)
+		t.Fatal("expected an undeclared option to be rejected")
+	}
+}
@@ -10,18 +10,67 @@
 // characters, and characters followed by a colon ":", to indicate an
 // argument is to follow. For example, an option string "x" recognizes
 // an option "-x", and an option string "x:" recognizes an option and
-// argument "-x argument".
+// argument "-x argument". The argument may also be attached to the
+// option, as in "-xargument"; in a bundle of several options, such as
+// "-abxargument", the remainder of the bundle following an option
+// which takes an argument is consumed as its argument. A character
+// followed by two colons "::" takes an optional argument, which is
+// only recognized when attached to the option, as in "-xargument";
+// the next argument on the command line is never consumed.
+//
+// A "+" at the very beginning of shortopts requests the POSIX
+// behaviour of stopping at the first operand (an argument which is
+// not an option), even if Config.Permute asks otherwise; the "+" is
+// not an option character. Without Permute, this is the default. The
+// POSIXLY_CORRECT environment variable has the same effect.
+//
+// A "-" at the very beginning of shortopts instead requests each
+// operand to be returned in order, among the options, as an OptArg
+// with the Option InOrder and the operand as its Argument; as for
+// tools such as linkers, where the position of an operand relative
+// to the options matters. Only the arguments following a "--"
+// terminator are then left over.
+//
+// A ":" at the beginning of shortopts (following any "+" or "-")
+// requests the quiet mode of C getopt: rather than a ParseError, an
+// unrecognized option is returned as an OptArg with the Option "?",
+// and an option missing its argument as one with the Option ":"; in
+// either case, with the offending option as the Argument, as in
+// OptArg{Option: "?", Argument: "-x", HasArgument: true}. This lets
+// the program report the mistake in its own way. Any other error is
+// still a ParseError.
+//
+// "W;" in shortopts declares "-W" as the vendor extension reserved by
+// POSIX, same as in glibc: "-W foo" (or "-Wfoo") is taken to mean
+// "--foo", and "-W foo=bar" to mean "--foo=bar". The option is
+// reported as the long option, same as if given directly.
 //
 // The longopts array specifies one option per element. Similarly to
 // how colon works in shortopts, the option may be followed by an
 // equals sign "=", to indicate an expected argument. For example,
 // "flag" recognizes the option "--flag", while "flag:" recognizes an
-// option and an argument "--flag=argument". The longopts array can be
+// option and an argument "--flag=argument". An option followed by "=?"
+// (or "==", a synonym) takes an optional argument: "flag=?"
+// recognizes both "--flag" and "--flag=argument", but never consumes
+// the next argument on the command line. The longopts array can be
 // empty or nil, to signify that no long options will be processed.
 //
+// A long option may also declare a short option as its alias, with a
+// vertical bar "|" followed by the option character, placed before
+// any "=" or "=?": for example, "output|o=" recognizes both
+// "--output=argument" and "-o argument". Both spellings are reported
+// as the long option, "--output", so there's only one name to check
+// for. The short option doesn't need to be listed in shortopts.
+// Aliases are resolved as the options are parsed, so that the
+// returned options can be switched on directly; the spelling the user
+// chose is lost, but can still be found in the argument at the
+// option's Index.
+//
 // The interpretation of options in the argument list may be cancelled
 // by the option "--" (double dash), which causes GetOpt to end
-// further argument processing and return the results so far.
+// further argument processing and return the results so far. A lone
+// "-" (single dash) is not an option, but an operand, conventionally
+// standing for the standard input or output.
 //
 // The recognized options will be returned in an array of OptArg, in
 // the order in which they were encountered.
@@ -45,25 +94,51 @@
 // And the options:
 //
 //    []OptArg{
-//        OptArg{Option: "-h", Argument: ""},
-//        OptArg{Option: "-v", Argument: ""},
-//        OptArg{Option: "-x", Argument: "asdf"},
-//        OptArg{Option: "-r", Argument: ""},
-//        OptArg{Option: "--flag", Argument: "arg"},
+//        OptArg{Option: "-h", Index: 0},
+//        OptArg{Option: "-v", Index: 1},
+//        OptArg{Option: "-x", Argument: "asdf", HasArgument: true, Index: 2},
+//        OptArg{Option: "-r", Index: 4},
+//        OptArg{Option: "--flag", Argument: "arg", HasArgument: true, Index: 5},
 //    }
+//
+// GetOptResult offers a number of optional behaviours, selected with
+// a Config, and sorts the leftover arguments into distinct buckets;
+// see Result. Parser returns the options one at a time, as they are
+// parsed. Bind declares the options with struct tags, and stores them
+// in the fields of the struct.
 
 package getopt
 
+import "errors"
 import "fmt"
+import "io"
 import "strings"
+import "unicode/utf8"
 
 // OptArg represents a single parsed option (and its argument, if
 // applicable), as parsed by GetOpt.
 type OptArg struct {
 	Option   string
 	Argument string
+
+	// HasArgument tells an option given with an empty argument (as
+	// in "--flag=") from one given without any argument. This
+	// matters for options which take an optional argument.
+	HasArgument bool
+
+	// Index is the position in the parsed argument list of the
+	// argument in which the option was found. All the options in a
+	// bundle (such as "-abc") share the same Index. For an option
+	// with its argument in the next argument (as in "-x argument"),
+	// this is the position of the option.
+	Index int
 }
 
+// InOrder is the Option of the operands, when they are returned in
+// order among the options, as requested by a leading "-" in
+// shortopts.
+const InOrder = "\x01"
+
 // Opt returns the Option from OptArg. It exists to maintain backward
 // compatibility with github.com/timtadh/getopt.
 func (o OptArg) Opt() string { return o.Option }
@@ -85,16 +160,169 @@ type ParseError struct {
 	Unexpected string
 	Expected   string
 
+	// Section and Description describe the option, as declared with
+	// Spec.Section and Spec.Description, and Hint is a short help
+	// text shown in parentheses after the message. They are only
+	// filled in with Config.Hints.
+	Section     string
+	Description string
+	Hint        string
+
+	// Token is the whole argument an unrecognized option character
+	// was found in, when it was part of a bundle of short options,
+	// and Offset is the byte offset of the character within Token;
+	// e.g. "-abx" and 3 for "-x", so that the bundle can be shown
+	// as "-ab^x". Neither GetOpt nor GetOptResult report any of the
+	// options preceding it in the bundle, as no options are
+	// returned along with an error; a Parser returns them from Next
+	// before the error.
+	Token  string
+	Offset int
+
+	// Err is the underlying cause of the problem, if any; e.g. the
+	// error from strconv, for an argument which is not a number.
+	Err error
+
 	// The problem was caused by the programmer, not the user.
 	// This can trigger a panic.
 	notUsersFault bool
+
+	// One of the sentinel errors, reported by Is.
+	kind error
 }
 
+// Sentinel errors, identifying the kind of a ParseError, for use with
+// errors.Is. For example:
+//
+//	if errors.Is(err, getopt.ErrMissingArgument) {
+//	    ...
+//	}
+var (
+	// ErrUnknownOption is an option which was not declared.
+	ErrUnknownOption = errors.New("option not recognized")
+
+	// ErrMissingArgument is an option given without its required
+	// argument.
+	ErrMissingArgument = errors.New("option requires an argument")
+
+	// ErrUnexpectedArgument is an argument given to an option which
+	// does not take one.
+	ErrUnexpectedArgument = errors.New("option does not take an argument")
+
+	// ErrAmbiguousOption is an abbreviation matching more than one
+	// long option.
+	ErrAmbiguousOption = errors.New("option is ambiguous")
+
+	// ErrInvalidArgument is an argument which could not be
+	// interpreted, e.g. as a number.
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrMissingOption is an option which is missing, although it
+	// is required (e.g. by another option).
+	ErrMissingOption = errors.New("missing option")
+
+	// ErrConflictingOptions is an option given together with
+	// another one, which it excludes.
+	ErrConflictingOptions = errors.New("conflicting options")
+
+	// ErrTooManyOperands is an operand given in excess of the number
+	// allowed.
+	ErrTooManyOperands = errors.New("too many operands")
+
+	// ErrRepeatedOption is an option given more times than allowed
+	// (see Spec.CountMax).
+	ErrRepeatedOption = errors.New("option repeated too many times")
+
+	// ErrUnknownCommand is a subcommand which was not declared (see
+	// Command).
+	ErrUnknownCommand = errors.New("command not recognized")
+
+	// ErrMissingCommand is a subcommand which is missing, although
+	// the command can't run without one (see Command).
+	ErrMissingCommand = errors.New("missing command")
+
+	// ErrVersion is the --version option intercepted by the parser,
+	// after printing the version; see Spec.Version. It is not a
+	// failure: the program should exit successfully.
+	ErrVersion = errors.New("version requested")
+
+	// ErrCompletion is the option declared with Spec.CompletionHook
+	// (or Spec.Complete) intercepted by the parser, after printing the
+	// completion script (or the candidates). It is not a failure: the
+	// program should exit successfully, unless the ParseError holds
+	// an Err, as when the script cannot be written.
+	ErrCompletion = errors.New("completion requested")
+
+	// ErrInvalidSpec is a programming error in the specification of
+	// the options, such as an option declared more than once.
+	ErrInvalidSpec = errors.New("invalid option specification")
+)
+
 func (err ParseError) Error() string {
+	msg := err.Message
 	if err.Opt != "" {
-		return fmt.Sprintf("%s: %s", err.Message, err.Opt)
+		msg = fmt.Sprintf("%s: %s", err.Message, err.Opt)
+	}
+	if err.Hint != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, err.Hint)
 	}
-	return err.Message
+	return msg
+}
+
+// Is reports whether the error is of the kind identified by target,
+// which is one of the sentinel errors, such as ErrUnknownOption.
+func (err ParseError) Is(target error) bool {
+	return err.kind != nil && err.kind == target
+}
+
+// Unwrap returns the underlying cause of the problem, if any.
+func (err ParseError) Unwrap() error {
+	return err.Err
+}
+
+// ParseErrors is a list of problems found while parsing the
+// arguments, as reported with Config.CollectErrors.
+type ParseErrors []*ParseError
+
+// Error lists all the errors, one per line.
+func (errs ParseErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the individual errors, for Go 1.20 and later.
+func (errs ParseErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+	return unwrapped
+}
+
+// Is reports whether any of the errors matches target, so that e.g.
+// errors.Is(err, ErrUnknownOption) works before Go 1.20, which does
+// not follow Unwrap returning a list.
+func (errs ParseErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors which matches target, so that
+// errors.As can find a *ParseError before Go 1.20; see Is.
+func (errs ParseErrors) As(target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
 }
 
 // Quote the value, e.g. to be presented as a literal in an error
@@ -145,126 +373,743 @@ func GetOptSafe(
 	optargs []OptArg,
 	err error,
 ) {
-	shorts, err := build_shorts(shortopts)
+	spec, err := Compile(shortopts, longopts)
 	if err != nil {
 		return nil, nil, err
 	}
+	return spec.Parse(args)
+}
+
+// GetOptLongOnly works like GetOpt, but also recognizes the long
+// options given with a single dash, as in "-flag" or "-flag=value",
+// like getopt_long_only(3) does; this suits X11 or Go style command
+// lines. An argument starting with a single dash is first looked up
+// among the long options, and otherwise parsed as a bundle of short
+// options. It is the same as GetOptResult with
+// Config.SingleDashLong.
+func GetOptLongOnly(
+	args []string,
+	shortopts string,
+	longopts []string,
+) (
+	leftovers []string,
+	optargs []OptArg,
+	err error,
+) {
+	r, err := GetOptResult(
+		args, shortopts, longopts, Config{SingleDashLong: true})
+	if eparse, ok := err.(*ParseError); ok && eparse.notUsersFault {
+		panic(err)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return r.Args(), r.Options, nil
+}
+
+// Config selects optional parsing behaviours for GetOptResult. The
+// zero value parses exactly like GetOpt.
+type Config struct {
+	// Passthrough tolerates unrecognized options: rather than
+	// failing with a ParseError, they are collected in
+	// Result.Unrecognized, and left in place in Result.Args. For a
+	// bundle of short options, each unrecognized character is
+	// reported on its own (e.g. "-x" out of "-axb").
+	Passthrough bool
+
+	// RequireEquals forbids the "--flag argument" form for long
+	// options which take an argument: the argument must be attached
+	// with an equals sign, as in "--flag=argument". A long option
+	// given without one is a ParseError, and the next argument on
+	// the command line is never consumed. This guards scripts
+	// against a forgotten argument silently swallowing an operand.
+	RequireEquals bool
+
+	// Permute scans the entire argument list for options, like GNU
+	// getopt does by default, rather than stopping at the first
+	// operand, as POSIX requires. Options and operands may then be
+	// freely interleaved, as in "program file.txt -v"; the operands
+	// are returned in Result.Positionals in their original order.
+	// The "--" terminator still ends option processing. A leading
+	// "+" in shortopts overrides it, and always stops at the first
+	// operand; so does the POSIXLY_CORRECT environment variable, if
+	// set (to anything) when the parsing begins, same as in glibc.
+	Permute bool
+
+	// NormalizeDashes treats Unicode lookalikes of the hyphen-minus
+	// (such as the en dash "–", or the em dash "—"), found at the
+	// start of an argument, as if they were a "-". Such characters
+	// are easily introduced by copying a command line from a word
+	// processor. Each normalized argument is reported in
+	// Result.Warnings. Arguments to options are never normalized.
+	NormalizeDashes bool
+
+	// TrimSpace trims any whitespace surrounding an argument which
+	// then looks like an option, such as " -v", before parsing it;
+	// for arguments coming from sloppy sources, such as a
+	// hand-edited file. Other arguments are left as they are, and
+	// so are arguments to options. Each trimmed argument is reported
+	// in Result.Warnings. Without it, " -v" is an operand, as it
+	// does not start with a "-".
+	TrimSpace bool
+
+	// CollapseDashes takes an argument starting with three or more
+	// dashes, such as "---verbose", to mean a long option, as if it
+	// started with two; each one is reported in Result.Warnings.
+	// Without it, "---verbose" is an unrecognized option. A run of
+	// dashes alone, such as "---", is never collapsed.
+	CollapseDashes bool
+
+	// AllowAbbrev lets long options be abbreviated to any prefix
+	// which is unambiguous among the declared long options, like
+	// GNU getopt_long does: "--verb" is then taken to mean
+	// "--verbose". The full name of the option is reported in
+	// OptArg.Option. An exact match always wins over an
+	// abbreviation; an ambiguous abbreviation is a ParseError listing
+	// the possible options, unless resolved otherwise according to
+	// the Ambiguity policy. To only allow abbreviating some of the
+	// options, see Spec.Abbreviatable.
+	AllowAbbrev bool
+
+	// SingleDashLong lets the long options also be spelled with a
+	// single dash, as in "-name" or "-maxdepth=2", like find(1)
+	// does. Only an exact match is taken as a long option, which
+	// then wins over a bundle of short options; any other argument
+	// starting with a single dash is still a bundle, so "-n" stays
+	// a short option, even if there's a long option "--name". The
+	// long option is reported with both dashes, e.g. "--name".
+	SingleDashLong bool
+
+	// Assign is the character separating a long option from its
+	// attached argument, as in "--key=value"; or '=' if zero. With
+	// e.g. ':', the option is given as "--key:value", and '=' is
+	// then an ordinary character. The longopts are still declared
+	// with '=' (as in "key="), though Usage shows the Assign
+	// character.
+	Assign rune
+
+	// IgnoreCase matches long options regardless of case, so that
+	// "--Help" and "--HELP" are both taken to mean "--help". The
+	// declared spelling of the option is reported in OptArg.Option;
+	// an attached argument keeps its case. Short options are always
+	// case sensitive, as "-v" and "-V" are conventionally distinct.
+	// Abbreviations (see AllowAbbrev) must still match the case of
+	// the option.
+	IgnoreCase bool
+
+	// Ambiguity selects how an ambiguous abbreviation is resolved,
+	// when AllowAbbrev is set.
+	Ambiguity Ambiguity
+
+	// AbbrevLimit, if positive, bounds the number of long options
+	// an abbreviation may match: one matching more is a ParseError,
+	// whatever the Ambiguity policy, and the search for the options
+	// stops as soon as the limit is exceeded. This keeps the cost of
+	// parsing untrusted arguments bounded, even with thousands of
+	// declared long options.
+	AbbrevLimit int
+
+	// CollectErrors keeps on parsing after a recoverable error, such
+	// as an unrecognized option, or an option missing its argument
+	// (when followed by another option), so that all of the user's
+	// mistakes can be reported at once. The errors are then returned
+	// together, as ParseErrors. A missing argument at the end of the
+	// argument list still ends parsing.
+	CollectErrors bool
+
+	// NumericOperands takes an argument made of a "-" followed by
+	// digits only, such as "-5", as an operand (a negative number),
+	// rather than an unrecognized option; unless any of the digits
+	// is declared as a short option, in which case it's parsed as
+	// usual.
+	NumericOperands bool
+
+	// RejectControl rejects any argument containing a control
+	// character (as classified by unicode.IsControl, including NUL,
+	// tab and newline) with a ParseError, before any option is
+	// parsed. Without it, control characters are ordinary
+	// characters, which can only make up an option if declared.
+	RejectControl bool
+
+	// Hints adds a short help text to each ParseError about an
+	// option: the section and description of the option, if the
+	// Spec declares them, or for an unrecognized long option, the
+	// declared options sharing the longest prefix with it, as in
+	// "option not recognized: --fmt (did you mean one of: Output
+	// control: --format, --form-feed?)".
+	Hints bool
+
+	// Repair fixes up a few common mistakes, in which an option got
+	// split in two by a stray space, and adds a warning about each
+	// fix to Result.Warnings:
+	//
+	//   - A long option taking an argument, followed by an argument
+	//     starting with the Assign character, as in "--flag =value",
+	//     is taken as "--flag=value".
+	//   - A lone "-", followed by an argument which is a single
+	//     declared short option character, as in "- x", is taken as
+	//     "-x". Without Repair, "-" is an operand.
+	//
+	// No other mistakes are repaired.
+	Repair bool
+}
+
+// Ambiguity is a policy for resolving an abbreviation of a long
+// option, which is a prefix of more than one of the declared long
+// options.
+//
+// The default, AmbiguityError, is the safest choice, and matches GNU
+// getopt_long: the user has to type out enough of the option to
+// disambiguate it. The other policies are more convenient, but
+// declaring a new long option can silently change the meaning of an
+// abbreviation which was previously accepted: with FirstDeclared,
+// only when the new option is declared ahead of the old one; with
+// Longest, whenever the new option is the longer one. Prefer
+// AmbiguityError unless the set of long options is stable.
+type Ambiguity int
+
+const (
+	// AmbiguityError rejects an ambiguous abbreviation with a
+	// ParseError, listing the possible options.
+	AmbiguityError Ambiguity = iota
+
+	// FirstDeclared picks the option which was declared first in
+	// longopts.
+	FirstDeclared
+
+	// Longest picks the option with the longest name; or, among
+	// several such options, the one which was declared first.
+	Longest
+)
+
+// GetOptResult works like GetOptSafe, but takes a Config, and returns
+// a Result which sorts the arguments that were not consumed as
+// options into distinct buckets.
+func GetOptResult(
+	args []string,
+	shortopts string,
+	longopts []string,
+	config Config,
+) (*Result, error) {
+	spec, err := Compile(shortopts, longopts)
+	if err != nil {
+		return nil, err
+	}
+	spec.Config = config
+	return spec.ParseResult(args)
+}
+
+// GetOptPacked parses a single string, holding a number of long
+// options separated by delim, such as "width=80;verbose" (with delim
+// ";"); this is useful for embedding options in a URL query, or an
+// environment variable. The long options are specified by longopts,
+// same as for GetOpt, but are given without the leading dashes.
+// Empty pieces (as in "a=1;;b=2") are skipped. OptArg.Index is the
+// position of the piece holding the option, counting the empty ones.
+//
+// An option which takes an argument requires it to be attached with
+// an equals sign, as with Config.RequireEquals: "width=80" is fine,
+// but "width" is a ParseError.
+func GetOptPacked(s, delim string, longopts []string) ([]OptArg, error) {
+	var args []string
+	var pos []int
+	for i, piece := range strings.Split(s, delim) {
+		if piece != "" {
+			args = append(args, "--"+piece)
+			pos = append(pos, i)
+		}
+	}
+	r, err := GetOptResult(args, "", longopts, Config{RequireEquals: true})
+	if err != nil {
+		return nil, err
+	}
+	for i := range r.Options {
+		r.Options[i].Index = pos[r.Options[i].Index]
+	}
+	return r.Options, nil
+}
+
+// Spec is a compiled specification of the accepted options. It can
+// be used to parse any number of argument lists, without validating
+// and processing shortopts and longopts each time. Once configured,
+// it can be used by several goroutines at once: parsing never
+// modifies it. It does write through it, though: into the variables
+// bound to the options (see StringVar and CompileStruct), and from
+// Stdin (see StdinSentinel); which should then not be shared between
+// goroutines parsing at the same time.
+//
+// Spec has methods for configuring the behaviour of individual
+// options, such as RestAsString. These take options by name, as they
+// would be reported in OptArg.Option (e.g. "-v" or "--verbose"), and
+// will panic if the option was not declared, same as GetOpt does for
+// programming errors.
+type Spec struct {
+	// Config selects optional parsing behaviours. It may be
+	// changed between the calls to Parse or ParseResult.
+	Config
+
+	// Stdin is read by the options marked with StdinSentinel. If
+	// nil, os.Stdin is read.
+	Stdin io.Reader
+
+	// Stdout is written by the options declared with Version. If
+	// nil, os.Stdout is written.
+	Stdout io.Writer
+
+	shorts  map[string]arity
+	longs   map[string]arity
+	index   *prefixIndex
+	aliases map[string]string
+
+	// folded maps the lower case spellings of the long options to
+	// the declared ones, for IgnoreCase.
+	folded map[string][]string
+
+	// ordered is set by a leading "+" in shortopts, and disables
+	// Permute; inOrder is set by a leading "-".
+	ordered bool
+	inOrder bool
+
+	// quiet is set by a leading ":" in shortopts.
+	quiet bool
+
+	// word is set by "W;" in shortopts.
+	word bool
+
+	// isShort is the classifier given to CompileFunc, if any.
+	isShort func(c rune) bool
+
+	// The options, in the order of their declaration.
+	shortNames []string
+	longNames  []string
+
+	rest    map[string]bool
+	literal map[string]bool
+
+	// abbrevs indexes the options marked with Abbreviatable.
+	abbrevs *prefixIndex
+
+	sections     map[string]string
+	descriptions map[string]string
+	metavars     map[string]string
+	operands     string
+	types        map[string]string
+	defaults     map[string]string
+	configured   map[string]string
+	hidden       map[string]bool
+	values       map[string][]string
+	env          map[string]string
+
+	// negated maps the negations declared by Negatable, such as
+	// "--no-color", to the options they negate.
+	negated map[string]string
+
+	// required lists the options marked with Required, requires the
+	// dependencies declared with Requires, and exclusive the sets
+	// declared with Exclusive.
+	required  []string
+	requires  []dependency
+	exclusive [][]string
+
+	// countMax limits how many times options can be given, for
+	// CountMax.
+	countMax map[string]int
+
+	// intercepts maps the options handled by the parser itself
+	// (such as --version, see Version) to the action taken instead
+	// of returning them; which returns the error to return instead.
+	intercepts map[string]func(OptArg) error
+
+	// completers complete the arguments of options, for Complete.
+	completers map[string]func(prefix string) []string
+
+	// validators check (and possibly normalize) the arguments of
+	// options, as they are parsed; bindings then store them.
+	validators map[string][]validator
+	bindings   map[string][]binding
+}
+
+// Compile validates shortopts and longopts, and returns a Spec ready
+// to parse argument lists. See the package documentation for a
+// description of the shortopts and longopts formats.
+//
+// Compile returns a ParseError if there is a programming error in
+// shortopts or longopts, such as an option specified more than once.
+// This allows validating an option specification coming from the
+// end user (e.g. when implementing getopt(1)) up front.
+func Compile(shortopts string, longopts []string) (*Spec, error) {
+	return CompileFunc(shortopts, longopts, nil)
+}
+
+// CompileFunc works like Compile, but lets isShort decide which
+// characters can be short options, e.g. unicode.IsLetter to only
+// allow letters. Declaring any other character as a short option
+// (including as an alias) is a programming error; and an argument
+// such as "-5", whose first character after the "-" is not accepted
+// by isShort, is not a bundle of short options, but an operand.
+//
+// If isShort is nil, any character other than ":" and "-" can be a
+// short option, same as with Compile.
+func CompileFunc(
+	shortopts string,
+	longopts []string,
+	isShort func(c rune) bool,
+) (*Spec, error) {
+	ordered := strings.HasPrefix(shortopts, "+")
+	inOrder := strings.HasPrefix(shortopts, "-")
+	if ordered || inOrder {
+		shortopts = shortopts[1:]
+	}
+	quiet := strings.HasPrefix(shortopts, ":")
+	shortopts = strings.TrimPrefix(shortopts, ":")
+	word := strings.Contains(shortopts, "W;")
+	shortopts = strings.Replace(shortopts, "W;", "W:", 1)
+	shorts, err := build_shorts(shortopts)
+	if err != nil {
+		return nil, err
+	}
 	longs, err := build_longs(longopts)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	aliases, err := build_aliases(longopts)
+	if err != nil {
+		return nil, err
 	}
-	leftovers = args
-	skip := false
-	emitopt := ""
-	for i, arg := range args {
-		leftovers = leftovers[1:]
-		if arg == "--" {
-			if skip {
-				return nil, nil, &ParseError{
-					Message:    "option requires an argument",
-					Opt:        emitopt,
-					Unexpected: q("--"),
-				}
+	for _, opt := range longopts {
+		long, short, ar := parse_long(opt)
+		if short == "" {
+			continue
+		}
+		if declared, has := shorts[short]; has && declared != ar {
+			return nil, &ParseError{
+				Message: fmt.Sprintf(
+					"option is declared with a different argument as %s", long),
+				Opt:           short,
+				Unexpected:    q(opt),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
 			}
+		}
+		shorts[short] = ar
+	}
+	shortNames := short_names(shortopts, longopts)
+	if err := check_shorts(shortNames, isShort); err != nil {
+		return nil, err
+	}
+	names := long_names(longopts)
+	folded := make(map[string][]string)
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		folded[lower] = append(folded[lower], name)
+	}
+	return &Spec{
+		shorts:       shorts,
+		longs:        longs,
+		index:        newPrefixIndex(names),
+		aliases:      aliases,
+		folded:       folded,
+		ordered:      ordered,
+		inOrder:      inOrder,
+		quiet:        quiet,
+		word:         word,
+		rest:         make(map[string]bool),
+		literal:      make(map[string]bool),
+		validators:   make(map[string][]validator),
+		bindings:     make(map[string][]binding),
+		sections:     make(map[string]string),
+		descriptions: make(map[string]string),
+		metavars:     make(map[string]string),
+		types:        make(map[string]string),
+		defaults:     make(map[string]string),
+		configured:   make(map[string]string),
+		hidden:       make(map[string]bool),
+		values:       make(map[string][]string),
+		env:          make(map[string]string),
+		negated:      make(map[string]string),
+		countMax:     make(map[string]int),
+		intercepts:   make(map[string]func(OptArg) error),
+		completers:   make(map[string]func(string) []string),
+		isShort:      isShort,
+		shortNames:   shortNames,
+		longNames:    names,
+	}, nil
+}
+
+// ValidateSpec checks shortopts and longopts for programming errors,
+// such as an option specified more than once, or a colon or an
+// equals sign without an option to go with it; and returns the first
+// one found as a ParseError, same as Compile would. It never panics,
+// so it can be used to validate an option specification coming from
+// the end user (e.g. when implementing getopt(1)), before looking at
+// any arguments.
+func ValidateSpec(shortopts string, longopts []string) error {
+	_, err := Compile(shortopts, longopts)
+	return err
+}
+
+// Parse works like GetOptSafe, but parses args according to the
+// compiled Spec (including its Config).
+func (s *Spec) Parse(args []string) (
+	leftovers []string,
+	optargs []OptArg,
+	err error,
+) {
+	r, err := s.ParseResult(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.Args(), r.Options, nil
+}
+
+// ParseResult works like GetOptResult, but parses args according to
+// the compiled Spec (including its Config).
+func (s *Spec) ParseResult(args []string) (*Result, error) {
+	return s.parseResult(args, nil)
+}
+
+// parseResult works like ParseResult, with the inherited options
+// preceding those parsed from args, for Command.
+func (s *Spec) parseResult(args []string, inherited Options) (*Result, error) {
+	p := s.NewParser(args)
+	p.r.Options = append(p.r.Options, inherited...)
+	var errs ParseErrors
+	for {
+		optarg, err := p.Next()
+		if err == io.EOF {
 			break
-		} else if skip {
-			if len(arg) > 0 && arg[0] == '-' {
-				return nil, nil, &ParseError{
-					Message:    "option requires an argument",
-					Opt:        emitopt,
-					Unexpected: fmt.Sprintf("next option: %q", arg),
-				}
+		} else if err != nil {
+			if !s.CollectErrors || errors.Is(err, ErrVersion) ||
+				errors.Is(err, ErrCompletion) {
+				return nil, err
 			}
-			optargs = append(optargs, OptArg{emitopt, arg})
-			skip = false
+			errs = append(errs, err.(*ParseError))
 			continue
 		}
-
-		if len(arg) >= 2 && arg[0] == '-' && arg[1] != '-' {
-			shargs := arg[1:]
-			for i, sharg := range shargs {
-				sa := "-" + string(sharg)
-				if found, opt, hasarg := short(sa, shorts); found {
-					if i != len(shargs)-1 && hasarg {
-						return nil, nil, &ParseError{
-							Message: "option requires an argument",
-							Opt:     sa,
-						}
-					} else if hasarg {
-						skip = true
-						emitopt = opt
-					} else {
-						optargs = append(optargs, OptArg{opt, ""})
-					}
-				} else {
-					return nil, nil, &ParseError{
-						Message:    "option not recognized",
-						Opt:        sa,
-						Unexpected: q(sa),
-						Expected:   "a short option",
-					}
-				}
-			}
-		} else if found, opt, oarg, hasarg, err := long(arg, longs); found {
-			if err != nil {
-				return nil, nil, err
-			} else if oarg != "" {
-				optargs = append(optargs, OptArg{opt, oarg})
-			} else if hasarg {
-				skip = true
-				emitopt = opt
-			} else {
-				optargs = append(optargs, OptArg{opt, ""})
-			}
-		} else {
-			if len(arg) > 0 && arg[0] == '-' {
-				return nil, nil, &ParseError{
-					Message:    "option not recognized",
-					Opt:        arg,
-					Unexpected: q(arg),
-					Expected:   "a short or a long option",
-				}
-			}
-			leftovers = args[i:]
-			break
+		p.r.Options = append(p.r.Options, optarg)
+	}
+	if err := s.fallback(p.r); err != nil {
+		if !s.CollectErrors {
+			return nil, err
 		}
+		errs = append(errs, err.(*ParseError))
+	}
+	for _, err := range s.check(p.r) {
+		if !s.CollectErrors {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errs
 	}
-	if skip {
-		return nil, nil, &ParseError{
+
+	return p.r, nil
+}
+
+// restOf captures the remaining arguments (and the argument attached
+// to the option, if any) as the argument of opt, for RestAsString.
+func restOf(
+	opt string,
+	ar arity,
+	attached string,
+	hasAttached bool,
+	tail []string,
+) (OptArg, error) {
+	words := tail
+	if hasAttached {
+		words = append([]string{attached}, tail...)
+	}
+	if len(words) == 0 && ar == argRequired {
+		return OptArg{}, &ParseError{
 			Message:    "option requires an argument",
-			Opt:        emitopt,
+			Opt:        opt,
 			Unexpected: "end of arguments",
 			Expected:   "an argument for an option",
+			kind:       ErrMissingArgument,
 		}
 	}
+	return OptArg{
+		Option:      opt,
+		Argument:    strings.Join(words, " "),
+		HasArgument: len(words) > 0,
+	}, nil
+}
+
+// arity tells whether an option takes an argument.
+type arity int
+
+const (
+	argNone arity = iota
+	argRequired
+	argOptional
+)
+
+// dashes are the characters which NormalizeDashes treats as "-".
+const dashes = "\u2010\u2011\u2012\u2013\u2014\u2015\u2212\ufe58\ufe63\uff0d"
 
-	return leftovers, optargs, nil
+// normalizeDashes replaces any leading dashes with "-".
+func normalizeDashes(arg string) string {
+	trimmed := strings.TrimLeft(arg, dashes)
+	n := utf8.RuneCountInString(arg[:len(arg)-len(trimmed)])
+	if n == 0 {
+		return arg
+	}
+	return strings.Repeat("-", n) + trimmed
+}
+
+// collapseDashes replaces three or more leading dashes with two.
+func collapseDashes(arg string) string {
+	name := strings.TrimLeft(arg, "-")
+	if n := len(arg) - len(name); n < 3 || name == "" {
+		return arg
+	}
+	return "--" + name
 }
 
-func build_longs(long []string) (map[string]bool, error) {
-	longs := make(map[string]bool)
+// parse_long splits a longopts entry into the option, the short
+// option aliased to it (if any), and its arity.
+func parse_long(opt string) (long, short string, ar arity) {
+	ar = argNone
+	if strings.HasSuffix(opt, "=?") || strings.HasSuffix(opt, "==") {
+		opt = opt[:len(opt)-2]
+		ar = argOptional
+	} else if strings.HasSuffix(opt, "=") {
+		opt = opt[:len(opt)-1]
+		ar = argRequired
+	}
+	if i := strings.Index(opt, "|"); i != -1 {
+		opt, short = opt[:i], "-"+opt[i+1:]
+	}
+	return "--" + opt, short, ar
+}
+
+// long_names lists the long options, in the order of declaration.
+func long_names(long []string) []string {
+	names := make([]string, len(long))
+	for i, opt := range long {
+		names[i], _, _ = parse_long(opt)
+	}
+	return names
+}
+
+// build_aliases maps the short options aliased to long options
+// ("help|h") to the long options.
+func build_aliases(long []string) (map[string]string, error) {
+	aliases := make(map[string]string)
 	for _, opt := range long {
-		hasarg := false
-		if opt[len(opt)-1] == '=' {
-			opt = opt[:len(opt)-1]
-			hasarg = true
+		opt, short, _ := parse_long(opt)
+		if short == "" {
+			continue
+		}
+		if utf8.RuneCountInString(short) != 2 || strings.ContainsAny(short[1:], ":-=|") {
+			return nil, &ParseError{
+				Message:       "alias must be a single option character",
+				Unexpected:    q(short[1:]),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			}
 		}
-		opt = "--" + opt
-		if _, has := longs[opt]; has {
+		if _, has := aliases[short]; has {
+			return nil, &ParseError{
+				Message:       "option specified more than once",
+				Unexpected:    q(short),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			}
+		}
+		aliases[short] = opt
+	}
+	return aliases, nil
+}
+
+// short_names lists the short options, in the order of declaration:
+// first those in shortopts, then those aliased to long options; each
+// only once, if declared both ways.
+func short_names(short string, long []string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, c := range short {
+		if c != ':' {
+			add("-" + string(c))
+		}
+	}
+	for _, opt := range long {
+		if _, short, _ := parse_long(opt); short != "" {
+			add(short)
+		}
+	}
+	return names
+}
+
+// check_shorts checks that the short options are made of characters
+// accepted by isShort, or by default, anything but ":" and "-".
+func check_shorts(names []string, isShort func(c rune) bool) error {
+	if isShort == nil {
+		isShort = func(c rune) bool { return c != ':' && c != '-' }
+	}
+	for _, name := range names {
+		c, _ := utf8.DecodeRuneInString(name[1:])
+		if !isShort(c) {
+			return &ParseError{
+				Message:       "character cannot be a short option",
+				Unexpected:    q(name[1:]),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			}
+		}
+	}
+	return nil
+}
+
+func build_longs(long []string) (map[string]arity, error) {
+	longs := make(map[string]arity)
+	for _, entry := range long {
+		if strings.TrimSpace(entry) == "" {
+			return nil, &ParseError{
+				Message:       "long option is empty",
+				Unexpected:    q(entry),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			}
+		}
+		opt, _, ar := parse_long(entry)
+		if opt == "--" {
+			return nil, &ParseError{
+				Message:       "long option has no name",
+				Unexpected:    q(entry),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			}
+		} else if _, has := longs[opt]; has {
 			return nil, &ParseError{
 				Message:       "option specified more than once",
 				Unexpected:    q(opt),
 				notUsersFault: true,
+				kind:          ErrInvalidSpec,
 			}
 		} else {
-			longs[opt] = hasarg
+			longs[opt] = ar
 		}
 	}
 	return longs, nil
 }
 
-func build_shorts(short string) (map[string]bool, error) {
-	shorts := make(map[string]bool)
+func build_shorts(short string) (map[string]arity, error) {
+	shorts := make(map[string]arity)
+	if strings.HasPrefix(short, ":") || strings.Contains(short, ":::") {
+		return nil, &ParseError{
+			Message:       "colon does not follow an option character",
+			Unexpected:    q(short),
+			notUsersFault: true,
+			kind:          ErrInvalidSpec,
+		}
+	}
 	for i, rc := range short {
 		c := string(rc)
 		if c == ":" {
@@ -275,50 +1120,53 @@ func build_shorts(short string) (map[string]bool, error) {
 				Message:       "option specified more than once",
 				Unexpected:    q(c),
 				notUsersFault: true,
+				kind:          ErrInvalidSpec,
 			}
 		} else {
-			shorts["-"+c] = false
-			if i+1 < len(short) {
-				nc := string(short[i+1])
-				if nc == ":" {
-					shorts["-"+c] = true
-				}
+			shorts["-"+c] = argNone
+			if strings.HasPrefix(short[i+len(c):], "::") {
+				shorts["-"+c] = argOptional
+			} else if strings.HasPrefix(short[i+len(c):], ":") {
+				shorts["-"+c] = argRequired
 			}
 		}
 	}
 	return shorts, nil
 }
 
-func short(arg string, shorts map[string]bool) (found bool, opt string, hasarg bool) {
-	if hasarg, has := shorts[arg]; has {
-		return true, arg, hasarg
+func short(arg string, shorts map[string]arity) (found bool, opt string, ar arity) {
+	if ar, has := shorts[arg]; has {
+		return true, arg, ar
 	}
-	return false, "", false
+	return false, "", argNone
 }
 
-func long(arg string, longs map[string]bool) (
+// long looks up the long option in arg, with the argument attached
+// after the assign character (see Config.Assign).
+func long(arg string, longs map[string]arity, assign string) (
 	found bool,
 	opt, rarg string,
-	hasarg bool,
+	ar arity,
 	err error,
 ) {
-	if i := strings.Index(arg, "="); i != -1 {
+	if i := strings.Index(arg, assign); i != -1 {
 		opt = arg[:i]
-		rarg = arg[i+1:]
+		rarg = arg[i+len(assign):]
 	} else {
 		opt = arg
 		rarg = ""
 	}
-	if hasarg, has := longs[opt]; has {
-		if !hasarg && rarg != "" {
+	if ar, has := longs[opt]; has {
+		if ar == argNone && rarg != "" {
 			err = &ParseError{
 				Message:    "option does not take an argument",
 				Opt:        opt,
 				Unexpected: q(rarg),
+				kind:       ErrUnexpectedArgument,
 			}
-			return false, "", "", false, err
+			return false, "", "", argNone, err
 		}
-		return true, opt, rarg, hasarg, nil
+		return true, opt, rarg, ar, nil
 	}
-	return false, "", "", false, nil
+	return false, "", "", argNone, nil
 }
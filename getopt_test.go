@@ -1,7 +1,11 @@
 package getopt
 
 import "testing"
+import "errors"
+import "os"
 import "reflect"
+import "strings"
+import "unicode"
 
 func errorQA(t *testing.T, err error) {
 	if eparse, ok := err.(*ParseError); ok {
@@ -12,9 +16,17 @@ func errorQA(t *testing.T, err error) {
 	}
 }
 
+// unsetPOSIXLY unsets POSIXLY_CORRECT for the duration of the test,
+// for the tests which depend on Config.Permute.
+func unsetPOSIXLY(t *testing.T) {
+	t.Setenv("POSIXLY_CORRECT", "")
+	os.Unsetenv("POSIXLY_CORRECT")
+}
+
 func Test_BuildShorts(t *testing.T) {
-	expected := map[string]bool{
-		"-h": false, "-v": false, "-e": false, "-x": true, "-y": true, "-z": true}
+	expected := map[string]arity{
+		"-h": argNone, "-v": argNone, "-e": argNone,
+		"-x": argRequired, "-y": argRequired, "-z": argRequired}
 	shorts, err := build_shorts("hvx:y:z:e")
 	if err != nil {
 		t.Fatal(err)
@@ -45,14 +57,14 @@ func Test_Short_arg(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	found, opt, hasarg := short("-y", shorts)
+	found, opt, ar := short("-y", shorts)
 	if !found {
 		t.Fatal("couldn't find -y")
 	}
 	if opt != "-y" {
 		t.Fatal("opt != -y")
 	}
-	if !hasarg {
+	if ar != argRequired {
 		t.Fatal("-y must have an arg")
 	}
 }
@@ -62,14 +74,14 @@ func Test_Short_noarg(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	found, opt, hasarg := short("-h", shorts)
+	found, opt, ar := short("-h", shorts)
 	if !found {
 		t.Fatal("couldn't find -h")
 	}
 	if opt != "-h" {
 		t.Fatal("opt != -h")
 	}
-	if hasarg {
+	if ar != argNone {
 		t.Fatal("-h must not have an arg")
 	}
 }
@@ -79,22 +91,22 @@ func Test_badShort(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	found, opt, hasarg := short("-r", shorts)
+	found, opt, ar := short("-r", shorts)
 	if found {
 		t.Fatal("could find -r")
 	}
 	if opt != "" {
 		t.Fatal("opt != ''")
 	}
-	if hasarg {
+	if ar != argNone {
 		t.Fatal("'' must not have an arg")
 	}
 }
 
 func Test_BuildLongs(t *testing.T) {
-	expected := map[string]bool{
-		"--help": false, "--verbose": false, "--empty": false, "--example": true,
-		"--yacc": true, "--zebra": true}
+	expected := map[string]arity{
+		"--help": argNone, "--verbose": argNone, "--empty": argNone,
+		"--example": argRequired, "--yacc": argRequired, "--zebra": argRequired}
 	shorts, err := build_longs([]string{"help", "verbose", "empty", "example=",
 		"yacc=", "zebra="})
 	if err != nil {
@@ -129,7 +141,7 @@ func Test_Long_noarg(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	found, opt, arg, hasarg, err := long("--help", longs)
+	found, opt, arg, ar, err := long("--help", longs, "=")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -142,7 +154,7 @@ func Test_Long_noarg(t *testing.T) {
 	if arg != "" {
 		t.Fatal("arg != ''")
 	}
-	if hasarg {
+	if ar != argNone {
 		t.Fatal("--help must not have arg")
 	}
 }
@@ -153,7 +165,7 @@ func Test_Long_arg(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	found, opt, arg, hasarg, err := long("--example=help", longs)
+	found, opt, arg, ar, err := long("--example=help", longs, "=")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -166,7 +178,7 @@ func Test_Long_arg(t *testing.T) {
 	if arg != "help" {
 		t.Fatal("arg != 'help'")
 	}
-	if !hasarg {
+	if ar != argRequired {
 		t.Fatal("--example must have arg")
 	}
 }
@@ -177,7 +189,7 @@ func Test_Long_argnext(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	found, opt, arg, hasarg, err := long("--example", longs)
+	found, opt, arg, ar, err := long("--example", longs, "=")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -190,7 +202,7 @@ func Test_Long_argnext(t *testing.T) {
 	if arg != "" {
 		t.Fatal("arg != ''")
 	}
-	if !hasarg {
+	if ar != argRequired {
 		t.Fatal("--example must have arg")
 	}
 }
@@ -201,7 +213,7 @@ func Test_Long_arg_unexpected(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	found, opt, arg, hasarg, err := long("--help=wat", longs)
+	found, opt, arg, ar, err := long("--help=wat", longs, "=")
 	errorQA(t, err)
 	if err == nil {
 		t.Fatal("expected an error")
@@ -215,7 +227,7 @@ func Test_Long_arg_unexpected(t *testing.T) {
 	if arg != "" {
 		t.Fatal("arg != ''")
 	}
-	if hasarg {
+	if ar != argNone {
 		t.Fatal("--help must not have arg")
 	}
 }
@@ -226,7 +238,7 @@ func Test_Long_badopt(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	found, opt, arg, hasarg, err := long("--wizard", longs)
+	found, opt, arg, ar, err := long("--wizard", longs, "=")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -239,7 +251,7 @@ func Test_Long_badopt(t *testing.T) {
 	if arg != "" {
 		t.Fatal("arg != ''")
 	}
-	if hasarg {
+	if ar != argNone {
 		t.Fatal("--wizards shouldn't have arg")
 	}
 }
@@ -530,7 +542,7 @@ func Test_Getopt_two_rshort_arg_several_leftovers(t *testing.T) {
 	}
 }
 
-func Test_Getopt_two_rshort_arg_bad_several_leftovers(t *testing.T) {
+func Test_Getopt_two_rshort_arg_attached_several_leftovers(t *testing.T) {
 	short := "hvx:y:z:e"
 	long := []string{
 		"help", "verbose", "example=", "yacc=", "zebra=", "empty",
@@ -539,15 +551,103 @@ func Test_Getopt_two_rshort_arg_bad_several_leftovers(t *testing.T) {
 		"-zy", "its a yacc!",
 		"fizzy", "bears", "are", "so", "--tasty",
 	}
-	_ = []string{
+	expected_leftovers := []string{
+		"its a yacc!",
 		"fizzy", "bears", "are", "so", "--tasty",
 	}
-	_, _, err := GetOpt(input, short, long)
-	t.Log(err)
-	errorQA(t, err)
-	if err == nil {
+	args, optargs, err := GetOpt(input, short, long)
+	if err != nil {
 		t.Fatal(err)
 	}
+	if !reflect.DeepEqual(args, expected_leftovers) {
+		t.Log("got", args)
+		t.Log("expected", expected_leftovers)
+		t.Fatal("recieved wrong leftovers")
+	}
+	if len(optargs) != 1 {
+		t.Log(optargs)
+		t.Fatal("expected one optarg")
+	}
+	if optargs[0].Opt() != "-z" || optargs[0].Arg() != "y" {
+		t.Fatal("expected to find -z 'y'")
+	}
+}
+
+func Test_Getopt_short_attached_args(t *testing.T) {
+	short := "abvx:o:"
+	for _, tc := range []struct {
+		input     []string
+		optargs   []OptArg
+		leftovers []string
+	}{
+		{
+			[]string{"-xfoo"},
+			[]OptArg{{Option: "-x", Argument: "foo", HasArgument: true}},
+			[]string{},
+		},
+		{
+			[]string{"-x", "foo"},
+			[]OptArg{{Option: "-x", Argument: "foo", HasArgument: true}},
+			[]string{},
+		},
+		{
+			[]string{"-abxfoo", "bar"},
+			[]OptArg{
+				{Option: "-a"},
+				{Option: "-b"},
+				{Option: "-x", Argument: "foo", HasArgument: true},
+			},
+			[]string{"bar"},
+		},
+		{
+			[]string{"-abx", "foo", "bar"},
+			[]OptArg{
+				{Option: "-a"},
+				{Option: "-b"},
+				{Option: "-x", Argument: "foo", HasArgument: true},
+			},
+			[]string{"bar"},
+		},
+		{
+			[]string{"-xvofile"},
+			[]OptArg{{Option: "-x", Argument: "vofile", HasArgument: true}},
+			[]string{},
+		},
+		{
+			[]string{"-avofile", "bar"},
+			[]OptArg{
+				{Option: "-a"},
+				{Option: "-v"},
+				{Option: "-o", Argument: "file", HasArgument: true},
+			},
+			[]string{"bar"},
+		},
+		{
+			[]string{"-vo", "file", "bar"},
+			[]OptArg{
+				{Option: "-v"},
+				{Option: "-o", Argument: "file", HasArgument: true},
+			},
+			[]string{"bar"},
+		},
+	} {
+		args, optargs, err := GetOpt(tc.input, short, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(optargs, tc.optargs) {
+			t.Log("input", tc.input)
+			t.Log("got", optargs)
+			t.Log("expected", tc.optargs)
+			t.Fatal("recieved wrong optargs")
+		}
+		if !reflect.DeepEqual(args, tc.leftovers) {
+			t.Log("input", tc.input)
+			t.Log("got", args)
+			t.Log("expected", tc.leftovers)
+			t.Fatal("recieved wrong leftovers")
+		}
+	}
 }
 
 func Test_Getopt_three_short_arg_several_leftovers(t *testing.T) {
@@ -656,3 +756,902 @@ func Test_Getoptsafe_programmerErrorDoesNotPanic(t *testing.T) {
 		return
 	}
 }
+
+func Test_BuildShorts_optional(t *testing.T) {
+	expected := map[string]arity{
+		"-h": argNone, "-C": argOptional, "-x": argRequired}
+	shorts, err := build_shorts("hC::x:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(shorts, expected) {
+		t.Log("got", shorts)
+		t.Log("expected", expected)
+		t.Fatal("Build shorts failed!")
+	}
+}
+
+func Test_BuildLongs_optional(t *testing.T) {
+	expected := map[string]arity{
+		"--help": argNone, "--color": argOptional, "--example": argRequired}
+	longs, err := build_longs([]string{"help", "color=?", "example="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(longs, expected) {
+		t.Log("got", longs)
+		t.Log("expected", expected)
+		t.Fatal("Build longs failed!")
+	}
+}
+
+func Test_BuildLongs_optionalSynonym(t *testing.T) {
+	expected := map[string]arity{"--color": argOptional, "--example": argRequired}
+	longs, err := build_longs([]string{"color==", "example="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(longs, expected) {
+		t.Log("got", longs)
+		t.Log("expected", expected)
+		t.Fatal("Build longs failed!")
+	}
+	_, opts, err := GetOptSafe(
+		[]string{"--color", "--color=never"}, "", []string{"color|c=="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOpts := []OptArg{
+		{Option: "--color", Index: 0},
+		{Option: "--color", Argument: "never", HasArgument: true, Index: 1},
+	}
+	if !reflect.DeepEqual(opts, expectedOpts) {
+		t.Log("got", opts)
+		t.Log("expected", expectedOpts)
+		t.Fatal("recieved wrong options")
+	}
+}
+
+func Test_Getopt_optional_args(t *testing.T) {
+	short := "hC::"
+	long := []string{"help", "color=?"}
+	for _, tc := range []struct {
+		input     []string
+		optargs   []OptArg
+		leftovers []string
+	}{
+		{
+			[]string{"-C", "auto"},
+			[]OptArg{{Option: "-C"}},
+			[]string{"auto"},
+		},
+		{
+			[]string{"-Cauto"},
+			[]OptArg{{Option: "-C", Argument: "auto", HasArgument: true}},
+			[]string{},
+		},
+		{
+			[]string{"-hCauto"},
+			[]OptArg{
+				{Option: "-h"},
+				{Option: "-C", Argument: "auto", HasArgument: true},
+			},
+			[]string{},
+		},
+		{
+			[]string{"-Ch", "auto"},
+			[]OptArg{{Option: "-C", Argument: "h", HasArgument: true}},
+			[]string{"auto"},
+		},
+		{
+			[]string{"--color", "auto"},
+			[]OptArg{{Option: "--color"}},
+			[]string{"auto"},
+		},
+		{
+			[]string{"--color=auto"},
+			[]OptArg{{Option: "--color", Argument: "auto", HasArgument: true}},
+			[]string{},
+		},
+		{
+			[]string{"--color="},
+			[]OptArg{{Option: "--color", HasArgument: true}},
+			[]string{},
+		},
+		{
+			[]string{"--color", "-h"},
+			[]OptArg{{Option: "--color"}, {Option: "-h", Index: 1}},
+			[]string{},
+		},
+	} {
+		args, optargs, err := GetOpt(tc.input, short, long)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(optargs, tc.optargs) {
+			t.Log("input", tc.input)
+			t.Log("got", optargs)
+			t.Log("expected", tc.optargs)
+			t.Fatal("recieved wrong optargs")
+		}
+		if !reflect.DeepEqual(args, tc.leftovers) {
+			t.Log("input", tc.input)
+			t.Log("got", args)
+			t.Log("expected", tc.leftovers)
+			t.Fatal("recieved wrong leftovers")
+		}
+	}
+}
+
+func Test_Abbrev(t *testing.T) {
+	names := long_names([]string{"verbose", "version", "ver", "help",
+		"example="})
+	for input, expected := range map[string]string{
+		"--verb":       "--verbose",
+		"--vers":       "--version",
+		"--ver":        "--ver",
+		"--h":          "--help",
+		"--ex=charles": "--example=charles",
+		"--wizard":     "--wizard",
+	} {
+		got, err := abbrev(input, newPrefixIndex(names), AmbiguityError, 0, "=")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != expected {
+			t.Log("got", got)
+			t.Log("expected", expected)
+			t.Fatal("recieved wrong expansion")
+		}
+	}
+	_, err := abbrev("--ve", newPrefixIndex(names), AmbiguityError, 0, "=")
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "--verbose, --version") {
+		t.Log(err)
+		t.Fatal("expected the error to list the candidates")
+	}
+}
+
+func Test_Getopt_abbrev(t *testing.T) {
+	long := []string{"verbose", "version", "example="}
+	input := []string{"--verb", "--vers", "--ex=charles", "--exam", "fizzy"}
+	r, err := GetOptResult(input, "", long, Config{AllowAbbrev: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--verbose", Index: 0},
+		{Option: "--version", Index: 1},
+		{Option: "--example", Argument: "charles", HasArgument: true,
+			Index: 2},
+		{Option: "--example", Argument: "fizzy", HasArgument: true,
+			Index: 3},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	_, err = GetOptResult(
+		[]string{"--verb=wat"}, "", long, Config{AllowAbbrev: true})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	_, err = GetOptResult(input, "", long, Config{})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected abbreviations to be rejected by default")
+	}
+}
+
+func Test_Abbrev_ambiguity(t *testing.T) {
+	names := long_names([]string{"verbose", "version-info", "ver", "help"})
+	for policy, expected := range map[Ambiguity]string{
+		FirstDeclared: "--verbose",
+		Longest:       "--version-info",
+	} {
+		got, err := abbrev("--ve=1", newPrefixIndex(names), policy, 0, "=")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != expected+"=1" {
+			t.Log("policy", policy)
+			t.Log("got", got)
+			t.Log("expected", expected)
+			t.Fatal("recieved wrong expansion")
+		}
+	}
+	_, err := abbrev("--ve", newPrefixIndex(names), AmbiguityError, 0, "=")
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, policy := range []Ambiguity{AmbiguityError, FirstDeclared, Longest} {
+		got, err := abbrev("--ver", newPrefixIndex(names), policy, 0, "=")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "--ver" {
+			t.Log("policy", policy)
+			t.Log("got", got)
+			t.Fatal("exact match must win")
+		}
+	}
+}
+
+func Test_Getopt_lone_dash(t *testing.T) {
+	unsetPOSIXLY(t)
+	args, optargs, err := GetOpt([]string{"-h", "-", "-v"}, "hvo:", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, []string{"-", "-v"}) {
+		t.Log("got", args)
+		t.Fatal("recieved wrong leftovers")
+	}
+	if len(optargs) != 1 {
+		t.Log(optargs)
+		t.Fatal("expected one optarg")
+	}
+
+	r, err := GetOptResult(
+		[]string{"-", "-v", "-o", "-", "fizzy"}, "hvo:", nil,
+		Config{Permute: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "-v", Index: 1},
+		{Option: "-o", Argument: "-", HasArgument: true, Index: 2},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Args(), []string{"-", "fizzy"}) {
+		t.Log("got", r.Args())
+		t.Fatal("recieved wrong leftovers")
+	}
+}
+
+func Test_Spec_reuse(t *testing.T) {
+	spec, err := Compile("hvx:", []string{"help", "example="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range [][]string{
+		{"-h", "-x", "asdf", "fizzy"},
+		{"-hxasdf", "fizzy"},
+		{"--help", "--example=asdf", "fizzy"},
+	} {
+		args, optargs, err := spec.Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(args, []string{"fizzy"}) {
+			t.Log("input", input)
+			t.Log("got", args)
+			t.Fatal("recieved wrong leftovers")
+		}
+		if len(optargs) != 2 || optargs[1].Arg() != "asdf" {
+			t.Log("input", input)
+			t.Log("got", optargs)
+			t.Fatal("recieved wrong optargs")
+		}
+	}
+}
+
+func Test_Spec_config(t *testing.T) {
+	unsetPOSIXLY(t)
+	spec, err := Compile("v", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := []string{"fizzy", "-v"}
+	args, optargs, err := spec.Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(optargs) != 0 || len(args) != 2 {
+		t.Log("got", args, optargs)
+		t.Fatal("expected parsing to stop at the first operand")
+	}
+	spec.Permute = true
+	args, optargs, err = spec.Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(optargs) != 1 || !reflect.DeepEqual(args, []string{"fizzy"}) {
+		t.Log("got", args, optargs)
+		t.Fatal("expected -v to be found")
+	}
+}
+
+func Test_Spec_compileError(t *testing.T) {
+	_, err := Compile("hvh", nil)
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	_, err = Compile("", []string{"help", "help="})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func Benchmark_Spec_Parse(b *testing.B) {
+	spec, err := Compile("hvx:y:z:e", []string{
+		"help", "verbose", "example=", "yacc=", "zebra=", "empty",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	input := []string{"-hv", "--example=charles", "-y", "yacc", "fizzy"}
+	for i := 0; i < b.N; i++ {
+		if _, _, err := spec.Parse(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_GetOpt(b *testing.B) {
+	long := []string{"help", "verbose", "example=", "yacc=", "zebra=", "empty"}
+	input := []string{"-hv", "--example=charles", "-y", "yacc", "fizzy"}
+	for i := 0; i < b.N; i++ {
+		if _, _, err := GetOpt(input, "hvx:y:z:e", long); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Test_GetOptPacked(t *testing.T) {
+	long := []string{"a=", "b=", "verbose", "color=?"}
+	optargs, err := GetOptPacked(";a=1;;verbose;b=2;color;", ";", long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []OptArg{
+		{Option: "--a", Argument: "1", HasArgument: true, Index: 1},
+		{Option: "--verbose", Index: 3},
+		{Option: "--b", Argument: "2", HasArgument: true, Index: 4},
+		{Option: "--color", Index: 5},
+	}
+	if !reflect.DeepEqual(optargs, expected) {
+		t.Log("got", optargs)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong optargs")
+	}
+	optargs, err = GetOptPacked("", ";", long)
+	if err != nil || len(optargs) != 0 {
+		t.Log(optargs, err)
+		t.Fatal("expected no optargs")
+	}
+	for _, input := range []string{"a;b=2", "a=1;c=3", "verbose=1"} {
+		_, err = GetOptPacked(input, ";", long)
+		errorQA(t, err)
+		if err == nil {
+			t.Log("input", input)
+			t.Fatal("expected an error")
+		}
+	}
+}
+
+func Test_Getopt_aliases(t *testing.T) {
+	short := "v"
+	long := []string{"help|h", "output|o=", "color|c=?", "verbose"}
+	input := []string{"-h", "--help", "-ofile", "--output", "file", "-vc",
+		"-cauto", "fizzy"}
+	args, optargs, err := GetOpt(input, short, long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []OptArg{
+		{Option: "--help", Index: 0},
+		{Option: "--help", Index: 1},
+		{Option: "--output", Argument: "file", HasArgument: true, Index: 2},
+		{Option: "--output", Argument: "file", HasArgument: true, Index: 3},
+		{Option: "-v", Index: 5},
+		{Option: "--color", Index: 5},
+		{Option: "--color", Argument: "auto", HasArgument: true, Index: 6},
+	}
+	if !reflect.DeepEqual(optargs, expected) {
+		t.Log("got", optargs)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong optargs")
+	}
+	if !reflect.DeepEqual(args, []string{"fizzy"}) {
+		t.Log("got", args)
+		t.Fatal("recieved wrong leftovers")
+	}
+	if !Options(optargs).Has("--help") || Options(optargs).Count("--help") != 2 {
+		t.Fatal("expected -h to count as --help")
+	}
+}
+
+func Test_Getopt_badAliases(t *testing.T) {
+	for _, long := range [][]string{
+		{"help|h", "hyper|h"},
+		{"help|"},
+		{"help|hh"},
+		{"help|:"},
+	} {
+		_, _, err := GetOptSafe(nil, "", long)
+		errorQA(t, err)
+		if err == nil {
+			t.Log("longopts", long)
+			t.Fatal("expected an error")
+		}
+	}
+}
+
+func Test_Getopt_errorKinds(t *testing.T) {
+	short := "vx:"
+	long := []string{"verbose", "version", "example="}
+	config := Config{AllowAbbrev: true}
+	for _, tc := range []struct {
+		input []string
+		kind  error
+	}{
+		{[]string{"-q"}, ErrUnknownOption},
+		{[]string{"--wizard"}, ErrUnknownOption},
+		{[]string{"-x"}, ErrMissingArgument},
+		{[]string{"--example", "--verbose"}, ErrMissingArgument},
+		{[]string{"--verbose=yes"}, ErrUnexpectedArgument},
+		{[]string{"--ver"}, ErrAmbiguousOption},
+	} {
+		_, err := GetOptResult(tc.input, short, long, config)
+		errorQA(t, err)
+		if !errors.Is(err, tc.kind) {
+			t.Log("input", tc.input)
+			t.Log("got", err)
+			t.Log("expected", tc.kind)
+			t.Fatal("recieved wrong kind of error")
+		}
+		for _, kind := range []error{
+			ErrUnknownOption, ErrMissingArgument,
+			ErrUnexpectedArgument, ErrAmbiguousOption,
+		} {
+			if kind != tc.kind && errors.Is(err, kind) {
+				t.Log("input", tc.input)
+				t.Log("got", err)
+				t.Fatal("error matches more than one kind")
+			}
+		}
+	}
+	_, err := GetOptResult(nil, "vv", nil, config)
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Log(err)
+		t.Fatal("expected ErrInvalidSpec")
+	}
+	_, err = GetOptResult([]string{"-q", "-x"}, short, long,
+		Config{CollectErrors: true})
+	if !errors.Is(err, ErrUnknownOption) || !errors.Is(err, ErrMissingArgument) {
+		t.Log(err)
+		t.Fatal("expected ParseErrors to match the kinds of all errors")
+	}
+}
+
+func Test_Getopt_index(t *testing.T) {
+	input := []string{"-abc", "-x", "asdf", "--flag=arg", "-xqwe",
+		"--example", "charles", "fizzy"}
+	_, optargs, err := GetOpt(input, "abcx:", []string{"flag=", "example="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{0, 0, 0, 1, 3, 4, 5}
+	var got []int
+	for _, o := range optargs {
+		got = append(got, o.Index)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Log("got", got)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong indices")
+	}
+	for _, o := range optargs {
+		if !strings.Contains(input[o.Index], strings.TrimLeft(o.Option, "-")) {
+			t.Log(o)
+			t.Fatal("index doesn't point at the option")
+		}
+	}
+}
+
+func Test_Getopt_plus_prefix(t *testing.T) {
+	unsetPOSIXLY(t)
+	input := []string{"-h", "-v", "fizzy", "-h", "bears"}
+	r, err := GetOptResult(input, "+hv", nil, Config{Permute: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{{Option: "-h", Index: 0}, {Option: "-v", Index: 1}}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Args(), input[2:]) {
+		t.Log("got", r.Args())
+		t.Fatal("expected parsing to stop at the first operand")
+	}
+
+	r, err = GetOptResult(input, "hv", nil, Config{Permute: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Options) != 3 {
+		t.Log("got", r.Options)
+		t.Fatal("expected permuting without the + prefix")
+	}
+
+	t.Setenv("POSIXLY_CORRECT", "")
+	r, err = GetOptResult(input, "hv", nil, Config{Permute: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Fatal("expected POSIXLY_CORRECT to stop at the first operand")
+	}
+
+	_, err = Compile("+", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = GetOptSafe([]string{"-+"}, "+hv", nil)
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected + not to be an option character")
+	}
+}
+
+func Test_Getopt_ValidateSpec(t *testing.T) {
+	if err := ValidateSpec("hvx:C::", []string{"help|h", "color=?"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		shortopts string
+		longopts  []string
+	}{
+		{"hvh", nil},
+		{"", []string{"help", "help="}},
+		{"::hv", nil},
+		{"+::hv", nil},
+		{"hx:::", nil},
+		{"", []string{"="}},
+		{"", []string{"=?"}},
+		{"", []string{"|h"}},
+	} {
+		err := ValidateSpec(tc.shortopts, tc.longopts)
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Log("input", tc.shortopts, tc.longopts)
+			t.Log("got", err)
+			t.Fatal("expected an invalid spec error")
+		}
+	}
+}
+
+func Test_Getopt_empty_longopt(t *testing.T) {
+	for _, longopts := range [][]string{
+		{"help", "", "version"},
+		{"help", " \t", "version"},
+	} {
+		_, _, err := GetOptSafe([]string{"--help"}, "", longopts)
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Log("input", longopts)
+			t.Log("got", err)
+			t.Fatal("expected an invalid spec error")
+		}
+	}
+	defer func() {
+		err := recover()
+		if eparse, ok := err.(*ParseError); ok && eparse.notUsersFault {
+			t.Log("panicked as expected")
+			return
+		}
+		t.Log("GetOpt was expected to panic")
+		t.Fail()
+	}()
+	GetOpt([]string{"--help"}, "", []string{"help", ""})
+}
+
+func Test_Getopt_CompileFunc(t *testing.T) {
+	for _, tc := range []struct {
+		shortopts string
+		longopts  []string
+	}{
+		{"ab1", nil},
+		{"ab", []string{"one|1"}},
+	} {
+		_, err := CompileFunc(tc.shortopts, tc.longopts, unicode.IsLetter)
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Log("input", tc.shortopts, tc.longopts)
+			t.Log("got", err)
+			t.Fatal("expected a digit to be rejected")
+		}
+	}
+	if err := ValidateSpec("ab-", nil); !errors.Is(err, ErrInvalidSpec) {
+		t.Log("got", err)
+		t.Fatal("expected - to be rejected by default")
+	}
+
+	spec, err := CompileFunc("ab", nil, unicode.IsLetter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := spec.ParseResult([]string{"-ab", "-1", "-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{{Option: "-a", Index: 0}, {Option: "-b", Index: 0}}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Args(), []string{"-1", "-a"}) {
+		t.Log("got", r.Args())
+		t.Fatal("expected -1 to be an operand")
+	}
+	_, err = spec.ParseResult([]string{"-a1"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrUnknownOption) {
+		t.Log("got", err)
+		t.Fatal("expected a digit within a bundle to be unknown")
+	}
+}
+
+func Test_Getopt_digit_in_bundle(t *testing.T) {
+	for _, tc := range []struct {
+		shortopts string
+		optargs   []OptArg
+	}{
+		{"n:5", []OptArg{
+			{Option: "-n", Argument: "5", HasArgument: true, Index: 0},
+		}},
+		{"n5", []OptArg{
+			{Option: "-n", Index: 0},
+			{Option: "-5", Index: 0},
+		}},
+		{"n::5", []OptArg{
+			{Option: "-n", Argument: "5", HasArgument: true, Index: 0},
+		}},
+	} {
+		_, optargs, err := GetOptSafe([]string{"-n5"}, tc.shortopts, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(optargs, tc.optargs) {
+			t.Log("shortopts", tc.shortopts)
+			t.Log("got", optargs)
+			t.Log("expected", tc.optargs)
+			t.Fatal("recieved wrong optargs")
+		}
+	}
+	_, _, err := GetOptSafe([]string{"-n5"}, "n", nil)
+	errorQA(t, err)
+	if err == nil || err.(*ParseError).Opt != "-5" {
+		t.Log(err)
+		t.Fatal("expected -5 not to be recognized")
+	}
+}
+
+func Test_Getopt_alias_arity_conflict(t *testing.T) {
+	for _, tc := range []struct {
+		shortopts string
+		longopts  []string
+	}{
+		{"c", []string{"color|c="}},
+		{"c:", []string{"color|c"}},
+		{"c::", []string{"color|c="}},
+	} {
+		_, _, err := GetOptSafe(nil, tc.shortopts, tc.longopts)
+		errorQA(t, err)
+		eparse, ok := err.(*ParseError)
+		if !ok || !eparse.notUsersFault || !errors.Is(err, ErrInvalidSpec) {
+			t.Log("input", tc.shortopts, tc.longopts)
+			t.Log("got", err)
+			t.Fatal("expected a programming error")
+		}
+	}
+	_, optargs, err := GetOptSafe([]string{"-c", "auto"}, "c:", []string{"color|c="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []OptArg{
+		{Option: "--color", Argument: "auto", HasArgument: true, Index: 0},
+	}
+	if !reflect.DeepEqual(optargs, expected) {
+		t.Log("got", optargs)
+		t.Log("expected", expected)
+		t.Fatal("expected agreeing declarations to be accepted")
+	}
+}
+
+func Test_Getopt_minus_prefix(t *testing.T) {
+	input := []string{"a.o", "-lm", "b.o", "-v", "-", "--", "-c.o"}
+	r, err := GetOptResult(input, "-l:v", nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: InOrder, Argument: "a.o", HasArgument: true, Index: 0},
+		{Option: "-l", Argument: "m", HasArgument: true, Index: 1},
+		{Option: InOrder, Argument: "b.o", HasArgument: true, Index: 2},
+		{Option: "-v", Index: 3},
+		{Option: InOrder, Argument: "-", HasArgument: true, Index: 4},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Args(), []string{"-c.o"}) {
+		t.Log("got", r.Args())
+		t.Fatal("recieved wrong leftovers")
+	}
+	if formatted := FormatOptions(r.Options); !reflect.DeepEqual(
+		formatted, []string{"a.o", "-lm", "b.o", "-v", "-"}) {
+		t.Log("got", formatted)
+		t.Fatal("recieved wrong formatted options")
+	}
+
+	_, _, err = GetOptSafe([]string{"--"}, "+-v", nil)
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected - not to be an option character")
+	}
+}
+
+func Test_Getopt_colon_prefix(t *testing.T) {
+	input := []string{"-vxv", "--wizard", "-o", "--", "fizzy"}
+	r, err := GetOptResult(input, ":vo:", nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "-v", Index: 0},
+		{Option: "?", Argument: "-x", HasArgument: true, Index: 0},
+		{Option: "-v", Index: 0},
+		{Option: "?", Argument: "--wizard", HasArgument: true, Index: 1},
+		{Option: ":", Argument: "-o", HasArgument: true, Index: 2},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Args(), []string{"fizzy"}) {
+		t.Log("got", r.Args())
+		t.Fatal("recieved wrong leftovers")
+	}
+
+	p, err := NewParser([]string{"-o"}, "+:o:", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	optarg, err := p.Next()
+	if err != nil || optarg.Option != ":" || p.OptOpt() != 'o' {
+		t.Log("got", optarg, err, p.OptOpt())
+		t.Fatal("expected a missing argument for -o")
+	}
+
+	_, _, err = GetOptSafe([]string{"-x"}, "vo:", nil)
+	errorQA(t, err)
+	if !errors.Is(err, ErrUnknownOption) {
+		t.Log("got", err)
+		t.Fatal("expected an error without the : prefix")
+	}
+}
+
+func Test_Getopt_W_word(t *testing.T) {
+	long := []string{"foo=", "verbose", "color=?"}
+	input := []string{
+		"-W", "foo=bar", "-vWverbose", "-W", "color", "-W", "foo", "x",
+		"fizzy",
+	}
+	r, err := GetOptResult(input, "vW;", long, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--foo", Argument: "bar", HasArgument: true, Index: 0},
+		{Option: "-v", Index: 2},
+		{Option: "--verbose", Index: 2},
+		{Option: "--color", Index: 3},
+		{Option: "--foo", Argument: "x", HasArgument: true, Index: 5},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Args(), []string{"fizzy"}) {
+		t.Log("got", r.Args())
+		t.Fatal("recieved wrong leftovers")
+	}
+
+	for _, input := range [][]string{{"-W", "wizard"}, {"-W"}} {
+		_, _, err := GetOptSafe(input, "vW;", long)
+		errorQA(t, err)
+		if err == nil {
+			t.Log("input", input)
+			t.Fatal("expected an error")
+		}
+	}
+
+	// Without "W;", -W is an ordinary option.
+	_, opts, err := GetOptSafe([]string{"-W", "foo"}, "W:", long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts[0].Option != "-W" {
+		t.Log("got", opts)
+		t.Fatal("recieved wrong options")
+	}
+}
+
+func Test_GetOptLongOnly(t *testing.T) {
+	long := []string{"display=", "sync", "verbose"}
+	input := []string{"-display=:0", "-sync", "-v", "--verbose", "-display", ":1", "x"}
+	args, optargs, err := GetOptLongOnly(input, "v", long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []OptArg{
+		{Option: "--display", Argument: ":0", HasArgument: true, Index: 0},
+		{Option: "--sync", Index: 1},
+		{Option: "-v", Index: 2},
+		{Option: "--verbose", Index: 3},
+		{Option: "--display", Argument: ":1", HasArgument: true, Index: 4},
+	}
+	if !reflect.DeepEqual(optargs, expected) {
+		t.Log("got", optargs)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(args, []string{"x"}) {
+		t.Log("got", args)
+		t.Fatal("recieved wrong leftovers")
+	}
+
+	_, _, err = GetOptLongOnly([]string{"-sx"}, "s", long)
+	errorQA(t, err)
+	if !errors.Is(err, ErrUnknownOption) {
+		t.Log("got", err)
+		t.Fatal("expected an unknown option error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	GetOptLongOnly(nil, "vv", long)
+}
+
+func Test_Compile_aliasInShortopts(t *testing.T) {
+	spec, err := Compile("vo:", []string{"output|o=", "verbose|v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Usage: prog [--output ARG] [--verbose] [ARG...]"
+	if usage := spec.Usage("prog"); usage != expected {
+		t.Log("got", usage)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong usage")
+	}
+	words := spec.NewParser(nil).CompletionWords(true)
+	if !reflect.DeepEqual(words, []string{"--output", "--verbose", "-v", "-o"}) {
+		t.Log("got", words)
+		t.Fatal("recieved wrong words")
+	}
+}
@@ -1 +1,3 @@
 module github.com/rollcat/getopt
+
+go 1.18
@@ -0,0 +1,139 @@
+package getopt
+
+import "fmt"
+import "io"
+import "strings"
+
+// PrintHelp writes a help text for prog to w: the Usage line, followed
+// by a table of the options, with their descriptions aligned. With
+// the shortopts "v", the longopts "output|o=" and "color=?", and
+// --color in a Section, it reads:
+//
+//	Usage: prog [-v] [--output ARG] [--color[=ARG]] [ARG...]
+//
+//	Options:
+//	  -v                 explain what is done
+//	  -o, --output=ARG   write to ARG (default: a.out)
+//
+//	Output control:
+//	      --color[=ARG]  colorize the output (one of: always, never)
+//
+// The options are grouped by their Section, with the options in no
+// section first; options marked Hidden are left out. The description
+// is followed by the allowed values (see WithValues), the Default,
+// and the environment variable (see Env), if any.
+func (s *Spec) PrintHelp(w io.Writer, prog string) error {
+	_, err := io.WriteString(w, s.Usage(prog)+"\n"+s.helpOptions())
+	return err
+}
+
+// helpOptions renders the table of the options, for PrintHelp.
+func (s *Spec) helpOptions() string {
+	groups := s.groupedOptions()
+	width := 0
+	for _, group := range groups {
+		for _, opt := range group.options {
+			if n := len([]rune(s.helpName(opt))); n > width {
+				width = n
+			}
+		}
+	}
+	var b strings.Builder
+	for _, group := range groups {
+		title := group.title
+		if title == "" {
+			title = "Options"
+		}
+		b.WriteString("\n" + title + ":\n")
+		for _, opt := range group.options {
+			line := fmt.Sprintf("  %-*s  %s", width, s.helpName(opt), helpText(opt))
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// optionGroup is the options in a Section, for the help texts.
+type optionGroup struct {
+	title   string
+	options []OptionSpec
+}
+
+// groupedOptions groups the options listed in the help texts by their
+// Section, in the order of their first appearance; the options in no
+// section come first, in a group without a title. The negations (see
+// Negatable) are left out, as they are listed along with the options
+// they negate, and so are the Hidden options. There are no empty
+// groups.
+func (s *Spec) groupedOptions() []optionGroup {
+	groups := []optionGroup{{}}
+	index := map[string]int{"": 0}
+	for _, opt := range s.describe() {
+		if _, has := s.negated[opt.Long]; has || opt.Hidden {
+			continue
+		}
+		i, has := index[opt.Group]
+		if !has {
+			i = len(groups)
+			index[opt.Group] = i
+			groups = append(groups, optionGroup{title: opt.Group})
+		}
+		groups[i].options = append(groups[i].options, opt)
+	}
+	if len(groups[0].options) == 0 {
+		groups = groups[1:]
+	}
+	return groups
+}
+
+// helpName renders the names of the option, along with its argument,
+// for PrintHelp.
+func (s *Spec) helpName(opt OptionSpec) string {
+	if opt.Metavar == "" {
+		opt.Metavar = "ARG"
+	}
+	if opt.Long == "" {
+		switch {
+		case opt.Optional:
+			return opt.Short + "[" + opt.Metavar + "]"
+		case opt.TakesArg:
+			return opt.Short + " " + opt.Metavar
+		}
+		return opt.Short
+	}
+	name := "    "
+	if opt.Short != "" {
+		name = opt.Short + ", "
+	}
+	if s.negated["--no-"+opt.Long[2:]] == opt.Long {
+		name += "--[no-]" + opt.Long[2:]
+	} else {
+		name += opt.Long
+	}
+	switch {
+	case opt.Optional:
+		name += "[" + s.assign() + opt.Metavar + "]"
+	case opt.TakesArg:
+		name += s.assign() + opt.Metavar
+	}
+	return name
+}
+
+// helpText renders the description of the option, for PrintHelp.
+func helpText(opt OptionSpec) string {
+	var notes []string
+	if opt.Values != nil {
+		notes = append(notes, "one of: "+strings.Join(opt.Values, ", "))
+	}
+	if opt.Default != "" {
+		notes = append(notes, "default: "+opt.Default)
+	}
+	if opt.Env != "" {
+		notes = append(notes, "env: "+opt.Env)
+	}
+	if len(notes) == 0 {
+		return opt.Description
+	}
+	return strings.TrimSpace(
+		opt.Description + " (" + strings.Join(notes, "; ") + ")")
+}
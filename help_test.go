@@ -0,0 +1,64 @@
+package getopt
+
+import "testing"
+import "os"
+import "strings"
+
+func Test_Spec_PrintHelp(t *testing.T) {
+	spec, err := Compile("vC::", []string{
+		"output|o=", "color=?", "debug", "sync", "level=",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Description("-v", "explain what is done").
+		Description("--output", "write to FILE").Default("-o", "a.out").
+		Description("--color", "colorize the output").
+		WithValues("--color", "always", "never").
+		Env("--level", "PROG_LEVEL").
+		Section("Output control", "--color", "-C").
+		Hidden("--debug").
+		Negatable("--sync")
+	var b strings.Builder
+	if err := spec.PrintHelp(&b, "prog"); err != nil {
+		t.Fatal(err)
+	}
+	expected := spec.Usage("prog") + "\n" +
+		"\n" +
+		"Options:\n" +
+		"  -v                 explain what is done\n" +
+		"  -o, --output=ARG   write to FILE (default: a.out)\n" +
+		"      --[no-]sync\n" +
+		"      --level=ARG    (env: PROG_LEVEL)\n" +
+		"\n" +
+		"Output control:\n" +
+		"  -C[ARG]\n" +
+		"      --color[=ARG]  colorize the output (one of: always, never)\n"
+	if b.String() != expected {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong help")
+	}
+}
+
+func ExampleSpec_PrintHelp() {
+	spec, err := Compile("v", []string{"output|o=", "color=?"})
+	if err != nil {
+		panic(err)
+	}
+	spec.Description("-v", "explain what is done").
+		Description("--output", "write to ARG").Default("--output", "a.out").
+		Description("--color", "colorize the output").
+		WithValues("--color", "always", "never").
+		Section("Output control", "--color")
+	spec.PrintHelp(os.Stdout, "prog")
+	// Output:
+	// Usage: prog [-v] [--output ARG] [--color[=ARG]] [ARG...]
+	//
+	// Options:
+	//   -v                 explain what is done
+	//   -o, --output=ARG   write to ARG (default: a.out)
+	//
+	// Output control:
+	//       --color[=ARG]  colorize the output (one of: always, never)
+}
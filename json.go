@@ -0,0 +1,39 @@
+package getopt
+
+import "encoding/json"
+import "io"
+
+// PrintJSON writes a description of the command line accepted by prog
+// to w, as JSON, for tools such as wrappers, graphical front ends, or
+// completion engines; it lists all of the options (including the
+// Hidden ones) as returned by Describe:
+//
+//	{
+//	  "program": "prog",
+//	  "usage": "Usage: prog [-v] [--output FILE] [ARG...]",
+//	  "options": [
+//	    {"short": "-v", "takesArg": false, "optional": false},
+//	    {"short": "-o", "long": "--output", "takesArg": true, ...}
+//	  ]
+//	}
+//
+// Like GCC's --help=..., it is meant to be printed upon request, such
+// as with an option declared as "help=?":
+//
+//	if arg, has := r.Get("--help"); has && arg == "json" {
+//	    spec.PrintJSON(os.Stdout, os.Args[0])
+//	}
+func (s *Spec) PrintJSON(w io.Writer, prog string) error {
+	options := s.describe()
+	if options == nil {
+		options = []OptionSpec{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Program  string       `json:"program"`
+		Usage    string       `json:"usage"`
+		Operands string       `json:"operands,omitempty"`
+		Options  []OptionSpec `json:"options"`
+	}{prog, s.Usage(prog), s.operands, options})
+}
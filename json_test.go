@@ -0,0 +1,50 @@
+package getopt
+
+import "testing"
+import "encoding/json"
+import "reflect"
+import "strings"
+
+func Test_Spec_PrintJSON(t *testing.T) {
+	spec, err := Compile("vn:", []string{"output|o=", "color=?", "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output string
+	spec.Description("--output", "write to FILE").Metavar("-o", "FILE").
+		StringVar(&output, "-o").Env("-o", "PROG_OUTPUT").
+		ValidInt("-n").Default("-n", "3").
+		WithValues("--color", "always", "never").
+		Section("Output control", "--color").
+		Hidden("--debug").
+		Operands("FILE")
+	var b strings.Builder
+	if err := spec.PrintJSON(&b, "prog"); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Program  string
+		Usage    string
+		Operands string
+		Options  []OptionSpec
+	}
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Log("got", b.String())
+		t.Fatal(err)
+	}
+	expected := []OptionSpec{
+		{Short: "-v"},
+		{Short: "-n", TakesArg: true, Default: "3", Type: "int"},
+		{Short: "-o", Long: "--output", TakesArg: true, Description: "write to FILE",
+			Metavar: "FILE", Env: "PROG_OUTPUT", Type: "string"},
+		{Long: "--color", TakesArg: true, Optional: true, Group: "Output control",
+			Values: []string{"always", "never"}},
+		{Long: "--debug", Hidden: true},
+	}
+	if got.Program != "prog" || got.Usage != spec.Usage("prog") ||
+		got.Operands != "FILE" || !reflect.DeepEqual(got.Options, expected) {
+		t.Log("got", got)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong description")
+	}
+}
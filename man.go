@@ -0,0 +1,95 @@
+package getopt
+
+import "fmt"
+import "io"
+import "strings"
+
+// PrintMan writes a manual page for prog to w, in the roff format of
+// man(7), for shipping with the program; such as:
+//
+//	.TH PROG 1
+//	.SH NAME
+//	prog \- frobnicate the files
+//	.SH SYNOPSIS
+//	prog [\-v] [\-\-output ARG] [ARG...]
+//	.SH OPTIONS
+//	.TP
+//	\fB\-o\fR, \fB\-\-output\fR=\fIARG\fR
+//	write to FILE (default: a.out)
+//
+// The page goes in the given section of the manual (1 for commands),
+// with the one-line summary of the program in the NAME section. The
+// SYNOPSIS is the same as rendered by Usage; the OPTIONS are the same
+// as listed by PrintHelp, with each Section as a subsection.
+func (s *Spec) PrintMan(w io.Writer, prog string, section int, summary string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s %d\n", roff(strings.ToUpper(prog)), section)
+	b.WriteString(".SH NAME\n")
+	b.WriteString(roff(prog) + " \\- " + roff(summary) + "\n")
+	b.WriteString(".SH SYNOPSIS\n")
+	b.WriteString(roffLine(s.synopsis(prog, "ARG", "ARG")) + "\n")
+	groups := s.groupedOptions()
+	if len(groups) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+	}
+	for _, group := range groups {
+		if group.title != "" {
+			b.WriteString(".SS " + roff(group.title) + "\n")
+		}
+		for _, opt := range group.options {
+			b.WriteString(".TP\n" + s.manName(opt) + "\n")
+			if text := helpText(opt); text != "" {
+				b.WriteString(roffLine(text) + "\n")
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// manName renders the names of the option, along with its argument,
+// for PrintMan.
+func (s *Spec) manName(opt OptionSpec) string {
+	var names []string
+	if opt.Short != "" {
+		names = append(names, `\fB`+roff(opt.Short)+`\fR`)
+	}
+	sep := " "
+	if opt.Long != "" {
+		long := opt.Long
+		if s.negated["--no-"+long[2:]] == long {
+			long = "--[no-]" + long[2:]
+		}
+		names = append(names, `\fB`+roff(long)+`\fR`)
+		sep = roff(s.assign())
+	}
+	if opt.Metavar == "" {
+		opt.Metavar = "ARG"
+	}
+	name := strings.Join(names, ", ")
+	switch {
+	case opt.Optional && opt.Long == "":
+		name += `[\fI` + roff(opt.Metavar) + `\fR]`
+	case opt.Optional:
+		name += "[" + sep + `\fI` + roff(opt.Metavar) + `\fR]`
+	case opt.TakesArg:
+		name += sep + `\fI` + roff(opt.Metavar) + `\fR`
+	}
+	return name
+}
+
+// roff escapes text for roff: backslashes, and dashes (which would
+// otherwise be rendered as hyphens).
+func roff(text string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(text)
+}
+
+// roffLine escapes a line of text for roff, including a leading "." or
+// "'", which would otherwise start a request.
+func roffLine(text string) string {
+	text = roff(text)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
@@ -0,0 +1,46 @@
+package getopt
+
+import "testing"
+import "strings"
+
+func Test_Spec_PrintMan(t *testing.T) {
+	spec, err := Compile("vC::", []string{"output|o=", "color=?", "debug", "sync"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Description("-v", ".explain what is done").
+		Description("--output", `write to FILE, or \ for none`).
+		Metavar("-o", "FILE").Default("-o", "a.out").
+		Section("Output control", "--color", "-C").
+		Hidden("--debug").
+		Negatable("--sync")
+	var b strings.Builder
+	if err := spec.PrintMan(&b, "prog", 1, "frobnicate the files"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `.TH PROG 1
+.SH NAME
+prog \- frobnicate the files
+.SH SYNOPSIS
+prog [\-v] [\-C[ARG]] [\-\-output FILE] [\-\-color[=ARG]] [\-\-[no\-]sync] [ARG...]
+.SH OPTIONS
+.TP
+\fB\-v\fR
+\&.explain what is done
+.TP
+\fB\-o\fR, \fB\-\-output\fR=\fIFILE\fR
+write to FILE, or \e for none (default: a.out)
+.TP
+\fB\-\-[no\-]sync\fR
+.SS Output control
+.TP
+\fB\-C\fR[\fIARG\fR]
+.TP
+\fB\-\-color\fR[=\fIARG\fR]
+`
+	if b.String() != expected {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong manual page")
+	}
+}
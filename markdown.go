@@ -0,0 +1,73 @@
+package getopt
+
+import "fmt"
+import "io"
+import "strings"
+
+// PrintMarkdown writes a reference of the command line accepted by
+// prog to w, in Markdown, for the documentation of a project; such as:
+//
+//	# prog
+//
+//	```
+//	prog [-v] [--output FILE] [ARG...]
+//	```
+//
+//	| Option | Description | Default | Environment |
+//	| --- | --- | --- | --- |
+//	| `-v` | explain what is done |  |  |
+//	| `-o`, `--output=FILE` | write to FILE | `a.out` | `PROG_OUTPUT` |
+//
+// The options are listed the same as by PrintHelp, with a table for
+// each Section, under a heading of its own. The allowed values (see
+// WithValues) are listed along with the description.
+func (s *Spec) PrintMarkdown(w io.Writer, prog string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n```\n%s\n```\n", prog, s.synopsis(prog, "ARG", "ARG"))
+	for _, group := range s.groupedOptions() {
+		if group.title != "" {
+			b.WriteString("\n## " + group.title + "\n")
+		}
+		b.WriteString("\n| Option | Description | Default | Environment |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, opt := range group.options {
+			text := opt.Description
+			if opt.Values != nil {
+				text = strings.TrimSpace(
+					text + " (one of: " + strings.Join(opt.Values, ", ") + ")")
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				s.markdownName(opt), markdown(text),
+				markdownCode(opt.Default), markdownCode(opt.Env))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownName renders the names of the option, along with its
+// argument, for PrintMarkdown.
+func (s *Spec) markdownName(opt OptionSpec) string {
+	name := s.helpName(opt)
+	if opt.Short != "" && opt.Long != "" {
+		return markdownCode(opt.Short) + ", " + markdownCode(name[len(opt.Short)+2:])
+	}
+	return markdownCode(strings.TrimSpace(name))
+}
+
+// markdown escapes text for a cell of a Markdown table.
+func markdown(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`",
+		"<", "&lt;", "[", `\[`,
+	).Replace(text)
+}
+
+// markdownCode renders text as code in a cell of a Markdown table, or
+// nothing if text is empty.
+func markdownCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(text, "|", `\|`) + "`"
+}
@@ -0,0 +1,46 @@
+package getopt
+
+import "testing"
+import "strings"
+
+func Test_Spec_PrintMarkdown(t *testing.T) {
+	spec, err := Compile("vC::", []string{"output|o=", "color=?", "debug", "sync"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Description("-v", "explain what is done").
+		Description("--output", "write to FILE | *stdout*").
+		Metavar("-o", "FILE").Default("-o", "a.out").Env("-o", "PROG_OUTPUT").
+		Description("--color", "colorize the output").
+		WithValues("--color", "always", "never").
+		Section("Output control", "--color", "-C").
+		Hidden("--debug").
+		Negatable("--sync")
+	var b strings.Builder
+	if err := spec.PrintMarkdown(&b, "prog"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# prog\n" +
+		"\n" +
+		"```\n" +
+		"prog [-v] [-C[ARG]] [--output FILE] [--color[=ARG]] [--[no-]sync] [ARG...]\n" +
+		"```\n" +
+		"\n" +
+		"| Option | Description | Default | Environment |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `-v` | explain what is done |  |  |\n" +
+		"| `-o`, `--output=FILE` | write to FILE \\| \\*stdout\\* | `a.out` | `PROG_OUTPUT` |\n" +
+		"| `--[no-]sync` |  |  |  |\n" +
+		"\n" +
+		"## Output control\n" +
+		"\n" +
+		"| Option | Description | Default | Environment |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `-C[ARG]` |  |  |  |\n" +
+		"| `--color[=ARG]` | colorize the output (one of: always, never) |  |  |\n"
+	if b.String() != expected {
+		t.Log("got\n" + b.String())
+		t.Log("expected\n" + expected)
+		t.Fatal("recieved wrong reference")
+	}
+}
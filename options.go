@@ -0,0 +1,341 @@
+package getopt
+
+import "fmt"
+import "strconv"
+import "strings"
+import "time"
+
+// Options is a list of parsed options, as returned by GetOpt, with
+// helper methods for looking up options by name. The name is the
+// option as reported in OptArg.Option, e.g. "-v" or "--verbose".
+type Options []OptArg
+
+// Has reports whether the option was given at all.
+func (opts Options) Has(name string) bool {
+	return opts.Count(name) > 0
+}
+
+// Set returns the names of all the options which were given, for
+// checking many flags at once, as in:
+//
+//	set := opts.Set()
+//	if set["-v"] {
+//	    ...
+//	}
+//
+// Options which take an argument are included as well; their
+// arguments can be looked up with Get or Values.
+func (opts Options) Set() map[string]bool {
+	set := make(map[string]bool, len(opts))
+	for _, o := range opts {
+		set[o.Option] = true
+	}
+	return set
+}
+
+// Count returns the number of times the option was given, such as 3
+// for "-vvv". A short option declared as the alias of a long one (as
+// in "verbose|v") is counted under the long option, so "-vv
+// --verbose" makes 3 for "--verbose". See also Spec.CountMax.
+func (opts Options) Count(name string) int {
+	n := 0
+	for _, o := range opts {
+		if o.Option == name {
+			n++
+		}
+	}
+	return n
+}
+
+// Get returns the argument of the option, and whether the option was
+// given at all. If the option was given more than once, the last
+// occurrence wins.
+func (opts Options) Get(name string) (string, bool) {
+	for i := len(opts) - 1; i >= 0; i-- {
+		if opts[i].Option == name {
+			return opts[i].Argument, true
+		}
+	}
+	return "", false
+}
+
+// Flag reports whether the named long option, or its negation (see
+// Spec.Negatable), was given last: on is true for "--color", and false
+// for "--no-color". If neither was given, given is false.
+func (opts Options) Flag(name string) (on, given bool) {
+	neg := "--no-" + strings.TrimPrefix(name, "--")
+	for i := len(opts) - 1; i >= 0; i-- {
+		switch opts[i].Option {
+		case name:
+			return true, true
+		case neg:
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// GivenWithValue reports whether the option was given with an
+// argument, even an empty one; e.g. for an option with an optional
+// argument, it tells "--debug=network" from a bare "--debug". Same
+// as with Get, if the option was given more than once, the last
+// occurrence wins. If the option was not given at all, it returns
+// false.
+func (opts Options) GivenWithValue(name string) bool {
+	for i := len(opts) - 1; i >= 0; i-- {
+		if opts[i].Option == name {
+			return opts[i].HasArgument
+		}
+	}
+	return false
+}
+
+// Values returns the arguments of every occurrence of the option, in
+// the order they were given, such as []string{"/a", "/b"} for
+// "-I /a -I /b". Occurrences without an argument are skipped. A short
+// alias of a long option is reported under the long name, so that
+// name covers both spellings.
+func (opts Options) Values(name string) []string {
+	var values []string
+	for _, o := range opts {
+		if o.Option == name && o.HasArgument {
+			values = append(values, o.Argument)
+		}
+	}
+	return values
+}
+
+// GetInt returns the argument of the option, converted to an int.
+// If the argument is not a valid integer, GetInt returns a
+// ParseError naming the option, which wraps the error from strconv.
+// If the option was not given at all, GetInt returns 0 and no error;
+// use Has to tell the difference.
+func (opts Options) GetInt(name string) (int, error) {
+	arg, has := opts.Get(name)
+	if !has {
+		return 0, nil
+	}
+	n, err := parseInt(name, arg, strconv.IntSize)
+	return int(n), err
+}
+
+func parseInt(name, arg string, bits int) (int64, error) {
+	n, err := strconv.ParseInt(arg, 10, bits)
+	if err != nil {
+		return 0, &ParseError{
+			Message:    "option expects an integer",
+			Opt:        name,
+			Unexpected: q(arg),
+			Expected:   "an integer",
+			Err:        err,
+			kind:       ErrInvalidArgument,
+		}
+	}
+	return n, nil
+}
+
+func parseUint(name, arg string, bits int) (uint64, error) {
+	n, err := strconv.ParseUint(arg, 10, bits)
+	if err != nil {
+		return 0, &ParseError{
+			Message:    "option expects a non-negative integer",
+			Opt:        name,
+			Unexpected: q(arg),
+			Expected:   "a non-negative integer",
+			Err:        err,
+			kind:       ErrInvalidArgument,
+		}
+	}
+	return n, nil
+}
+
+func parseFloat(name, arg string, bits int) (float64, error) {
+	f, err := strconv.ParseFloat(arg, bits)
+	if err != nil {
+		return 0, &ParseError{
+			Message:    "option expects a number",
+			Opt:        name,
+			Unexpected: q(arg),
+			Expected:   "a number, such as 1.5",
+			Err:        err,
+			kind:       ErrInvalidArgument,
+		}
+	}
+	return f, nil
+}
+
+func parseBool(name, arg string) (bool, error) {
+	b, err := strconv.ParseBool(arg)
+	if err != nil {
+		return false, &ParseError{
+			Message:    "option expects a boolean",
+			Opt:        name,
+			Unexpected: q(arg),
+			Expected:   "true or false",
+			Err:        err,
+			kind:       ErrInvalidArgument,
+		}
+	}
+	return b, nil
+}
+
+// Duration returns the argument of the option, parsed with
+// time.ParseDuration. If the argument is not a valid duration,
+// Duration returns a ParseError naming the option, which wraps the
+// error from the time package. If the option was not given at all,
+// Duration returns 0 and no error; use Has to tell the difference.
+func (opts Options) Duration(name string) (time.Duration, error) {
+	arg, has := opts.Get(name)
+	if !has {
+		return 0, nil
+	}
+	return parseDuration(name, arg)
+}
+
+func parseDuration(name, arg string) (time.Duration, error) {
+	d, err := time.ParseDuration(arg)
+	if err != nil {
+		return 0, &ParseError{
+			Message:    "option expects a duration",
+			Opt:        name,
+			Unexpected: q(arg),
+			Expected:   "a duration, such as 1m30s",
+			Err:        err,
+			kind:       ErrInvalidArgument,
+		}
+	}
+	return d, nil
+}
+
+// AllOrNone checks that either all of the named options were given,
+// or none of them were; e.g. "--tls-cert" and "--tls-key" only make
+// sense together. Otherwise, it returns a ParseError naming the
+// missing options.
+func (opts Options) AllOrNone(names ...string) error {
+	var present, missing []string
+	for _, name := range names {
+		if opts.Has(name) {
+			present = append(present, name)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	if len(present) == 0 || len(missing) == 0 {
+		return nil
+	}
+	return &ParseError{
+		Message: fmt.Sprintf(
+			"option must be given together with %s",
+			strings.Join(missing, ", ")),
+		Opt:        present[0],
+		Unexpected: "missing " + strings.Join(missing, ", "),
+		Expected:   "all or none of " + strings.Join(names, ", "),
+		kind:       ErrMissingOption,
+	}
+}
+
+// Expand substitutes references to other options, written as
+// "${name}", in the arguments of the options; e.g. with
+// "--in=data.txt --out=${in}.bak", the argument of "--out" becomes
+// "data.txt.bak". The name is that of a long option, without the
+// leading dashes (as in "${in}" for "--in"), or a short option
+// character (as in "${i}" for "-i"); a long option takes precedence.
+// A reference stands for the argument of the last occurrence of the
+// option, itself expanded. Write "$$" for a literal "$".
+//
+// Expand returns a new list of options. A reference to an option
+// which was not given, or a chain of references leading back to
+// itself, is a ParseError.
+func (opts Options) Expand() (Options, error) {
+	e := &expander{
+		opts:     opts,
+		values:   make(map[string]string),
+		visiting: make(map[string]bool),
+	}
+	expanded := make(Options, len(opts))
+	for i, o := range opts {
+		e.visiting[o.Option] = true
+		arg, err := e.expand(o.Option, o.Argument)
+		delete(e.visiting, o.Option)
+		if err != nil {
+			return nil, err
+		}
+		o.Argument = arg
+		expanded[i] = o
+	}
+	return expanded, nil
+}
+
+// expander keeps the state of Options.Expand.
+type expander struct {
+	opts     Options
+	values   map[string]string
+	visiting map[string]bool
+}
+
+// expand substitutes the references in the argument of opt.
+func (e *expander) expand(opt, arg string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(arg, '$')
+		if i == -1 || i == len(arg)-1 {
+			b.WriteString(arg)
+			return b.String(), nil
+		}
+		b.WriteString(arg[:i])
+		if arg[i+1] == '$' {
+			b.WriteByte('$')
+			arg = arg[i+2:]
+			continue
+		}
+		end := strings.IndexByte(arg[i:], '}')
+		if arg[i+1] != '{' || end == -1 {
+			b.WriteByte('$')
+			arg = arg[i+1:]
+			continue
+		}
+		value, err := e.resolve(opt, arg[i+2:i+end])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		arg = arg[i+end+1:]
+	}
+}
+
+// resolve returns the expanded value of the option referenced by
+// name, from within the argument of opt.
+func (e *expander) resolve(opt, name string) (string, error) {
+	ref := "--" + name
+	if !e.opts.Has(ref) {
+		ref = "-" + name
+	}
+	if value, has := e.values[ref]; has {
+		return value, nil
+	}
+	arg, has := e.opts.Get(ref)
+	if !has {
+		return "", &ParseError{
+			Message:    "option references an option which was not given",
+			Opt:        opt,
+			Unexpected: q("${" + name + "}"),
+			kind:       ErrInvalidArgument,
+		}
+	}
+	if e.visiting[ref] {
+		return "", &ParseError{
+			Message:    "option references itself",
+			Opt:        opt,
+			Unexpected: q("${" + name + "}"),
+			kind:       ErrInvalidArgument,
+		}
+	}
+	e.visiting[ref] = true
+	value, err := e.expand(ref, arg)
+	delete(e.visiting, ref)
+	if err != nil {
+		return "", err
+	}
+	e.values[ref] = value
+	return value, nil
+}
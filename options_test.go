@@ -0,0 +1,298 @@
+package getopt
+
+import "testing"
+import "errors"
+import "reflect"
+import "strconv"
+import "time"
+
+func Test_Options_accessors(t *testing.T) {
+	_, optargs, err := GetOpt(
+		[]string{"-vvv", "-n", "5", "--name=fizzy", "--verbose", "-n7"},
+		"vn:", []string{"verbose", "name="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options(optargs)
+	if !opts.Has("-v") || !opts.Has("--verbose") || opts.Has("-x") {
+		t.Fatal("Has failed")
+	}
+	if opts.Count("-v") != 3 || opts.Count("--verbose") != 1 {
+		t.Log("got", opts.Count("-v"), opts.Count("--verbose"))
+		t.Fatal("Count failed")
+	}
+	if arg, has := opts.Get("--name"); !has || arg != "fizzy" {
+		t.Log("got", arg, has)
+		t.Fatal("Get failed")
+	}
+	if arg, has := opts.Get("--wizard"); has || arg != "" {
+		t.Log("got", arg, has)
+		t.Fatal("Get failed")
+	}
+	n, err := opts.GetInt("-n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 7 {
+		t.Log("got", n)
+		t.Fatal("GetInt should return the last occurrence")
+	}
+	n, err = opts.GetInt("-x")
+	if err != nil || n != 0 {
+		t.Log("got", n, err)
+		t.Fatal("GetInt failed on an absent option")
+	}
+}
+
+func Test_Options_Count_alias(t *testing.T) {
+	_, optargs, err := GetOpt(
+		[]string{"-vv", "-dv", "--verbose", "-d", "--debug"},
+		"", []string{"verbose|v", "debug|d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options(optargs)
+	if opts.Count("--verbose") != 4 || opts.Count("--debug") != 3 ||
+		opts.Count("-v") != 0 {
+		t.Log("got", opts.Count("--verbose"), opts.Count("--debug"))
+		t.Fatal("Count failed")
+	}
+}
+
+func Test_Options_GivenWithValue(t *testing.T) {
+	long := []string{"debug=?", "trace=?", "quiet=?"}
+	r, err := GetOptResult(
+		[]string{"--debug", "--trace=network", "--quiet=", "--trace"},
+		"", long, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GivenWithValue("--debug") {
+		t.Fatal("expected a bare --debug")
+	}
+	if r.GivenWithValue("--trace") {
+		t.Fatal("expected the last --trace to win")
+	}
+	if !r.GivenWithValue("--quiet") {
+		t.Fatal("expected --quiet with an empty value")
+	}
+	r, err = GetOptResult(
+		[]string{"--debug=network"}, "", long, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.GivenWithValue("--debug") {
+		t.Fatal("expected --debug with a value")
+	}
+	if r.GivenWithValue("--trace") {
+		t.Fatal("expected an absent option not to have a value")
+	}
+}
+
+func Test_Options_Values(t *testing.T) {
+	_, optargs, err := GetOpt(
+		[]string{
+			"-I", "/a", "--define=X", "--include=/b", "-v", "--define",
+			"-I/c", "--define=Y",
+		},
+		"v", []string{"include|I=", "define=?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options(optargs)
+	expected := []string{"/a", "/b", "/c"}
+	if values := opts.Values("--include"); !reflect.DeepEqual(values, expected) {
+		t.Log("got", values)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong values")
+	}
+	expected = []string{"X", "Y"}
+	if values := opts.Values("--define"); !reflect.DeepEqual(values, expected) {
+		t.Log("got", values)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong values")
+	}
+	if values := opts.Values("-v"); values != nil {
+		t.Log("got", values)
+		t.Fatal("expected no values for a flag")
+	}
+}
+
+func Test_Options_Set(t *testing.T) {
+	r, err := GetOptResult(
+		[]string{"-vv", "-o", "a.out", "--dry-run"},
+		"vqo:", []string{"dry-run", "help"}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"-v": true, "-o": true, "--dry-run": true}
+	set := r.Set()
+	if !reflect.DeepEqual(set, expected) {
+		t.Log("got", set)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong set")
+	}
+	if set["-q"] || set["--help"] {
+		t.Fatal("expected absent options not to be set")
+	}
+}
+
+func Test_Options_GetInt_error(t *testing.T) {
+	opts := Options{{Option: "-n", Argument: "five", HasArgument: true}}
+	_, err := opts.GetInt("-n")
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if err.Error() != "option expects an integer: -n" {
+		t.Log(err)
+		t.Fatal("expected the error to name the option")
+	}
+	var enum *strconv.NumError
+	if !errors.As(err, &enum) {
+		t.Log(err)
+		t.Fatal("expected the error to wrap a *strconv.NumError")
+	}
+}
+
+func Test_Options_AllOrNone(t *testing.T) {
+	names := []string{"--tls-cert", "--tls-key", "--tls-ca"}
+	for _, opts := range []Options{
+		{},
+		{{Option: "-v"}},
+		{
+			{Option: "--tls-ca", Argument: "ca.pem", HasArgument: true},
+			{Option: "--tls-key", Argument: "key.pem", HasArgument: true},
+			{Option: "--tls-cert", Argument: "cert.pem", HasArgument: true},
+		},
+	} {
+		if err := opts.AllOrNone(names...); err != nil {
+			t.Log(opts)
+			t.Fatal(err)
+		}
+	}
+	opts := Options{
+		{Option: "--tls-key", Argument: "key.pem", HasArgument: true},
+	}
+	err := opts.AllOrNone(names...)
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	expected := "option must be given together with --tls-cert, --tls-ca: --tls-key"
+	if err.Error() != expected {
+		t.Log("got", err)
+		t.Log("expected", expected)
+		t.Fatal("expected the error to name the missing options")
+	}
+}
+
+func Test_Options_Expand(t *testing.T) {
+	_, optargs, err := GetOpt(
+		[]string{
+			"--in=data.txt", "--out=${in}.bak", "-d", "${out}/${i}",
+			"-i", "x", "--cost=$$5 ${nope", "--verbose",
+		},
+		"d:i:", []string{"in=", "out=", "cost=", "verbose"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expanded, err := Options(optargs).Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--in", Argument: "data.txt", HasArgument: true, Index: 0},
+		{Option: "--out", Argument: "data.txt.bak", HasArgument: true,
+			Index: 1},
+		{Option: "-d", Argument: "data.txt.bak/x", HasArgument: true,
+			Index: 2},
+		{Option: "-i", Argument: "x", HasArgument: true, Index: 4},
+		{Option: "--cost", Argument: "$5 ${nope", HasArgument: true,
+			Index: 6},
+		{Option: "--verbose", Index: 7},
+	}
+	if !reflect.DeepEqual(expanded, expected) {
+		t.Log("got", expanded)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong expansion")
+	}
+	if optargs[1].Argument != "${in}.bak" {
+		t.Fatal("Expand must not modify the original options")
+	}
+}
+
+func Test_Options_Expand_errors(t *testing.T) {
+	for _, tc := range []struct {
+		opts     Options
+		expected string
+	}{
+		{
+			Options{
+				{Option: "--out", Argument: "${in}.bak", HasArgument: true},
+			},
+			"option references an option which was not given: --out",
+		},
+		{
+			Options{
+				{Option: "--out", Argument: "${out}.bak", HasArgument: true},
+			},
+			"option references itself: --out",
+		},
+		{
+			Options{
+				{Option: "--a", Argument: "${b}", HasArgument: true},
+				{Option: "--b", Argument: "${c}", HasArgument: true},
+				{Option: "--c", Argument: "x${a}", HasArgument: true},
+			},
+			"option references itself: --c",
+		},
+	} {
+		_, err := tc.opts.Expand()
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidArgument) || err.Error() != tc.expected {
+			t.Log(tc.opts)
+			t.Log("got", err)
+			t.Log("expected", tc.expected)
+			t.Fatal("recieved wrong error")
+		}
+	}
+}
+
+func Test_Options_Duration(t *testing.T) {
+	long := []string{"timeout=", "delay="}
+	for _, input := range [][]string{{"--timeout=60s"}, {"--timeout", "1m"}} {
+		r, err := GetOptResult(input, "", long, Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := r.Duration("--timeout")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != time.Minute {
+			t.Log("input", input)
+			t.Log("got", d)
+			t.Fatal("recieved wrong duration")
+		}
+		if d, err := r.Duration("--delay"); d != 0 || err != nil {
+			t.Log("got", d, err)
+			t.Fatal("expected no duration for an absent option")
+		}
+	}
+
+	r, err := GetOptResult([]string{"--timeout=soon"}, "", long, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Duration("--timeout")
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Log(err)
+		t.Fatal("expected an invalid argument error")
+	}
+	if err.Error() != "option expects a duration: --timeout" {
+		t.Log(err)
+		t.Fatal("recieved wrong error")
+	}
+}
@@ -0,0 +1,520 @@
+package getopt
+
+import "fmt"
+import "io"
+import "os"
+import "strings"
+import "unicode"
+import "unicode/utf8"
+
+// Parser parses an argument list one option at a time. It is useful
+// when the options need to be acted upon as soon as they are seen,
+// e.g. when the meaning of an option depends on the ones before it.
+//
+// A Parser is created with NewParser or Spec.NewParser, and consumed
+// by calling Next until it returns io.EOF:
+//
+//	p, err := getopt.NewParser(os.Args[1:], "vo:", nil)
+//	...
+//	for {
+//	    optarg, err := p.Next()
+//	    if err == io.EOF {
+//	        break
+//	    } else if err != nil {
+//	        ...
+//	    }
+//	    ...
+//	}
+//	leftovers := p.Args()
+type Parser struct {
+	spec *Spec
+	args []string
+	r    *Result
+
+	// next is the index of the next argument to look at.
+	next int
+
+	// bundle is what is left to parse of a bundle of short options
+	// (e.g. "bc" after "-a" has been returned from "-abc"), bundletok
+	// is the whole argument it came from, and bundleidx its index.
+	bundle    string
+	bundletok string
+	bundleidx int
+
+	// pending are the errors found up front, by RejectControl, to
+	// be returned before parsing begins.
+	pending []*ParseError
+	started bool
+
+	// ordered disables Permute, by a leading "+" in shortopts or
+	// POSIXLY_CORRECT.
+	ordered bool
+
+	// literal is set once a LiteralSwitch is seen.
+	literal bool
+
+	// counts are the numbers of times the options limited by
+	// CountMax have been given.
+	counts map[string]int
+
+	// optarg and optopt are reported by OptArg and OptOpt.
+	optarg string
+	optopt rune
+
+	done bool
+}
+
+// NewParser validates shortopts and longopts, same as Compile, and
+// returns a Parser for args.
+func NewParser(args []string, shortopts string, longopts []string) (
+	*Parser,
+	error,
+) {
+	spec, err := Compile(shortopts, longopts)
+	if err != nil {
+		return nil, err
+	}
+	return spec.NewParser(args), nil
+}
+
+// NewParser returns a Parser for args, which parses them according to
+// the compiled Spec (including its Config).
+func (s *Spec) NewParser(args []string) *Parser {
+	_, posix := os.LookupEnv("POSIXLY_CORRECT")
+	return &Parser{
+		spec:    s,
+		args:    args,
+		r:       &Result{args: args[:0:0], spec: s},
+		ordered: s.ordered || posix,
+	}
+}
+
+// Next returns the next option, or io.EOF if there are no more.
+//
+// Any other error is a *ParseError, and is not fatal: calling Next
+// again resumes parsing after the offending option, same as the
+// CollectErrors mode does.
+func (p *Parser) Next() (OptArg, error) {
+	optarg, err := p.step()
+	if intercept, has := p.spec.intercepts[optarg.Option]; has && err == nil {
+		optarg, err = OptArg{}, intercept(optarg)
+	}
+	p.optarg, p.optopt = optarg.Argument, 0
+	if eparse, ok := err.(*ParseError); ok {
+		if p.spec.Hints {
+			p.spec.hint(eparse)
+		}
+		if strings.HasPrefix(eparse.Opt, "-") && !strings.HasPrefix(eparse.Opt, "--") {
+			p.optopt, _ = utf8.DecodeRuneInString(eparse.Opt[1:])
+		}
+		if quiet, ok := p.quiet(eparse); ok {
+			return quiet, nil
+		}
+	}
+	return optarg, err
+}
+
+// quiet turns err into an option, for a leading ":" in shortopts, and
+// reports whether it did.
+func (p *Parser) quiet(err *ParseError) (OptArg, bool) {
+	if !p.spec.quiet {
+		return OptArg{}, false
+	}
+	optarg := OptArg{Argument: err.Opt, HasArgument: true, Index: p.next - 1}
+	switch err.kind {
+	case ErrUnknownOption:
+		optarg.Option = "?"
+	case ErrMissingArgument:
+		optarg.Option = ":"
+	default:
+		return OptArg{}, false
+	}
+	return optarg, true
+}
+
+// OptArg returns the argument of the option last returned by Next,
+// or "" if it had none; same as the optarg variable in C.
+func (p *Parser) OptArg() string { return p.optarg }
+
+// OptOpt returns the short option character which caused the last
+// error returned by Next, such as 'x' for an unrecognized "-x", or 0
+// if there was no error, or the error was not about a short option;
+// same as the optopt variable in C.
+func (p *Parser) OptOpt() rune { return p.optopt }
+
+// step finds the next option, for Next.
+func (p *Parser) step() (OptArg, error) {
+	if !p.started {
+		p.started = true
+		if p.spec.RejectControl {
+			p.pending = controlErrors(p.args)
+		}
+	}
+	if len(p.pending) > 0 {
+		err := p.pending[0]
+		p.pending = p.pending[1:]
+		return OptArg{}, err
+	}
+	for {
+		if p.bundle != "" {
+			if optarg, ok, err := p.short(); ok || err != nil {
+				return p.validate(optarg, err)
+			}
+			continue
+		}
+		if p.literal && !p.done {
+			p.r.terminate(p.args[p.next:])
+			p.done = true
+		}
+		if p.done || p.next >= len(p.args) {
+			p.done = true
+			return OptArg{}, io.EOF
+		}
+		if optarg, ok, err := p.scan(); ok || err != nil {
+			return p.validate(optarg, err)
+		}
+	}
+}
+
+// validate runs the validators and the bindings of the option, if
+// parsed without an error.
+func (p *Parser) validate(optarg OptArg, err error) (OptArg, error) {
+	if err != nil {
+		return optarg, err
+	}
+	if p.spec.literal[optarg.Option] {
+		p.literal = true
+	}
+	if max, has := p.spec.countMax[optarg.Option]; has {
+		if p.counts == nil {
+			p.counts = make(map[string]int)
+		}
+		p.counts[optarg.Option]++
+		if p.counts[optarg.Option] > max {
+			return OptArg{}, &ParseError{
+				Message: fmt.Sprintf(
+					"option cannot be given more than %d times", max),
+				Opt:        optarg.Option,
+				Unexpected: fmt.Sprintf("%d times", p.counts[optarg.Option]),
+				Expected:   fmt.Sprintf("at most %d times", max),
+				kind:       ErrRepeatedOption,
+			}
+		}
+	}
+	for _, valid := range p.spec.validators[optarg.Option] {
+		if err := valid(&optarg); err != nil {
+			return OptArg{}, err
+		}
+	}
+	for _, bind := range p.spec.bindings[optarg.Option] {
+		if err := bind(optarg); err != nil {
+			return OptArg{}, err
+		}
+	}
+	return optarg, nil
+}
+
+// Args returns all arguments which were not consumed as options, in
+// their original order, same as Result.Args. It is only complete once
+// Next has returned io.EOF.
+func (p *Parser) Args() []string { return p.r.Args() }
+
+// scan looks at the next argument, and reports whether it has made an
+// option out of it.
+func (p *Parser) scan() (optarg OptArg, ok bool, err error) {
+	config := p.spec.Config
+	i, arg := p.next, p.args[p.next]
+	p.next++
+
+	if config.TrimSpace {
+		trimmed := strings.TrimSpace(arg)
+		if config.NormalizeDashes {
+			trimmed = normalizeDashes(trimmed)
+		}
+		if strings.HasPrefix(trimmed, "-") {
+			arg = strings.TrimSpace(arg)
+		}
+	}
+	if config.NormalizeDashes {
+		arg = normalizeDashes(arg)
+	}
+	if config.CollapseDashes {
+		arg = collapseDashes(arg)
+	}
+	if arg != p.args[i] {
+		p.r.warn("interpreted %q as %q", p.args[i], arg)
+	}
+	if arg == "--" {
+		p.r.terminate(p.args[p.next:])
+		p.done = true
+		return OptArg{}, false, nil
+	}
+
+	if config.Repair && arg == "-" && p.next < len(p.args) &&
+		utf8.RuneCountInString(p.args[p.next]) == 1 {
+		if _, has := p.spec.shorts["-"+p.args[p.next]]; has {
+			next := p.args[p.next]
+			p.next++
+			p.r.warn("interpreted %q %q as %q", arg, next, arg+next)
+			arg += next
+		}
+	}
+
+	if config.SingleDashLong && len(arg) >= 2 && arg[0] == '-' &&
+		arg[1] != '-' && p.spec.declared("-"+arg) {
+		arg = "-" + arg
+	}
+
+	if config.IgnoreCase && strings.HasPrefix(arg, "--") {
+		folded, err := p.spec.fold(arg)
+		if err != nil {
+			return OptArg{}, false, err
+		}
+		arg = folded
+	}
+
+	if index := p.spec.abbrevIndex(); index != nil &&
+		strings.HasPrefix(arg, "--") && !p.spec.declared(arg) {
+		expanded, err := abbrev(arg, index, config.Ambiguity, config.AbbrevLimit,
+			p.spec.assign())
+		if err != nil {
+			return OptArg{}, false, err
+		}
+		arg = expanded
+	}
+
+	if config.NumericOperands && p.spec.numeric(arg) {
+		return p.operand(i, arg)
+	}
+
+	if len(arg) >= 2 && arg[0] == '-' && arg[1] != '-' {
+		if c, _ := utf8.DecodeRuneInString(arg[1:]); p.spec.isShort != nil &&
+			!p.spec.isShort(c) {
+			return p.operand(i, arg)
+		}
+		p.bundle, p.bundletok, p.bundleidx = arg[1:], arg, i
+		return OptArg{}, false, nil
+	}
+
+	if optarg, ok, err := p.long(i, arg); ok || err != nil {
+		return optarg, ok, err
+	}
+
+	// A lone "-" is an operand, conventionally standing for the
+	// standard input or output.
+	if len(arg) > 1 && arg[0] == '-' {
+		if config.Passthrough {
+			p.r.unrecognize(arg)
+			return OptArg{}, false, nil
+		}
+		return OptArg{}, false, &ParseError{
+			Message:    "option not recognized",
+			Opt:        arg,
+			Unexpected: q(arg),
+			Expected:   "a short or a long option",
+			kind:       ErrUnknownOption,
+		}
+	}
+	return p.operand(i, arg)
+}
+
+// long parses arg, found at index i, as a long option, and reports
+// whether it has made an option out of it; if not, and there's no
+// error, arg is not a declared long option.
+func (p *Parser) long(i int, arg string) (optarg OptArg, ok bool, err error) {
+	config := p.spec.Config
+	found, opt, oarg, ar, err := long(arg, p.spec.longs, p.spec.assign())
+	if err != nil {
+		return OptArg{}, false, err
+	} else if found {
+		attached := strings.Contains(arg, p.spec.assign())
+		if config.Repair && !attached && ar != argNone && p.next < len(p.args) &&
+			strings.HasPrefix(p.args[p.next], p.spec.assign()) {
+			next := p.args[p.next]
+			p.next++
+			p.r.warn("interpreted %q %q as %q", arg, next, arg+next)
+			oarg, attached = strings.TrimPrefix(next, p.spec.assign()), true
+		}
+		if p.spec.rest[opt] {
+			optarg, err := p.restOf(opt, ar, oarg, attached, i)
+			return optarg, err == nil, err
+		} else if attached && ar != argNone &&
+			(oarg != "" || ar == argOptional || config.RequireEquals) {
+			return OptArg{
+				Option: opt, Argument: oarg, HasArgument: true, Index: i,
+			}, true, nil
+		} else if ar == argRequired && config.RequireEquals {
+			return OptArg{}, false, &ParseError{
+				Message: fmt.Sprintf(
+					"option requires an argument (use %s%sVALUE)", opt,
+					p.spec.assign()),
+				Opt:      opt,
+				Expected: q(opt + p.spec.assign() + "VALUE"),
+				kind:     ErrMissingArgument,
+			}
+		} else if ar == argRequired {
+			optarg, err := p.argument(opt, i)
+			return optarg, err == nil, err
+		}
+		return OptArg{Option: opt, Index: i}, true, nil
+	}
+	return OptArg{}, false, nil
+}
+
+// operand takes arg, found at index i, as an operand. Unless in the
+// Permute mode, it ends parsing; unless returned as an option, for a
+// leading "-" in shortopts.
+func (p *Parser) operand(i int, arg string) (OptArg, bool, error) {
+	if p.spec.inOrder {
+		return OptArg{
+			Option: InOrder, Argument: arg, HasArgument: true, Index: i,
+		}, true, nil
+	}
+	if p.spec.Permute && !p.ordered {
+		p.r.positional(arg)
+		return OptArg{}, false, nil
+	}
+	p.r.positional(p.args[i:]...)
+	p.done = true
+	return OptArg{}, false, nil
+}
+
+// short takes the next option off the current bundle, and reports
+// whether it has made an option out of it.
+func (p *Parser) short() (optarg OptArg, ok bool, err error) {
+	c, size := utf8.DecodeRuneInString(p.bundle)
+	sa := "-" + string(c)
+	offset := len(p.bundletok) - len(p.bundle)
+	rest := p.bundle[size:]
+	p.bundle = rest
+
+	found, opt, ar := short(sa, p.spec.shorts)
+	if !found {
+		if p.spec.Passthrough {
+			p.r.unrecognize(sa)
+			return OptArg{}, false, nil
+		}
+		return OptArg{}, false, &ParseError{
+			Message:    "option not recognized",
+			Opt:        sa,
+			Unexpected: q(sa),
+			Expected:   "a short option",
+			Token:      p.bundletok,
+			Offset:     offset,
+			kind:       ErrUnknownOption,
+		}
+	}
+	if opt == "-W" && p.spec.word {
+		return p.word(rest)
+	}
+	if long, has := p.spec.aliases[opt]; has {
+		opt = long
+	}
+	if p.spec.rest[opt] {
+		p.bundle = ""
+		optarg, err := p.restOf(opt, ar, rest, rest != "", p.bundleidx)
+		return optarg, err == nil, err
+	} else if ar != argNone && rest != "" {
+		p.bundle = ""
+		return OptArg{
+			Option: opt, Argument: rest, HasArgument: true,
+			Index: p.bundleidx,
+		}, true, nil
+	} else if ar == argRequired {
+		optarg, err := p.argument(opt, p.bundleidx)
+		return optarg, err == nil, err
+	}
+	return OptArg{Option: opt, Index: p.bundleidx}, true, nil
+}
+
+// word parses the long option given to "-W", for "W;" in shortopts;
+// either attached, or in the next argument.
+func (p *Parser) word(attached string) (optarg OptArg, ok bool, err error) {
+	p.bundle = ""
+	word := attached
+	if word == "" {
+		optarg, err := p.argument("-W", p.bundleidx)
+		if err != nil {
+			return OptArg{}, false, err
+		}
+		word = optarg.Argument
+	}
+	if optarg, ok, err := p.long(p.bundleidx, "--"+word); ok || err != nil {
+		return optarg, ok, err
+	}
+	return OptArg{}, false, &ParseError{
+		Message:    "option not recognized",
+		Opt:        "--" + word,
+		Unexpected: q("-W " + word),
+		Expected:   "a long option",
+		kind:       ErrUnknownOption,
+	}
+}
+
+// argument takes the next argument as the argument of opt, which was
+// given at index i.
+func (p *Parser) argument(opt string, i int) (OptArg, error) {
+	if p.next >= len(p.args) {
+		return OptArg{}, &ParseError{
+			Message:    "option requires an argument",
+			Opt:        opt,
+			Unexpected: "end of arguments",
+			Expected:   "an argument for an option",
+			kind:       ErrMissingArgument,
+		}
+	}
+	arg := p.args[p.next]
+	if arg == "--" || len(arg) > 1 && arg[0] == '-' {
+		// The next argument is left alone, to be parsed as usual
+		// if the caller chooses to go on.
+		err := &ParseError{
+			Message:    "option requires an argument",
+			Opt:        opt,
+			Unexpected: fmt.Sprintf("next option: %q", arg),
+			kind:       ErrMissingArgument,
+		}
+		if arg == "--" {
+			err.Unexpected = q("--")
+		}
+		return OptArg{}, err
+	}
+	p.next++
+	return OptArg{
+		Option: opt, Argument: arg, HasArgument: true, Index: i,
+	}, nil
+}
+
+// restOf captures the remaining arguments as the argument of opt, for
+// RestAsString, and ends parsing.
+func (p *Parser) restOf(
+	opt string,
+	ar arity,
+	attached string,
+	hasAttached bool,
+	i int,
+) (OptArg, error) {
+	tail := p.args[p.next:]
+	p.next = len(p.args)
+	p.done = true
+	optarg, err := restOf(opt, ar, attached, hasAttached, tail)
+	optarg.Index = i
+	return optarg, err
+}
+
+// controlErrors lists the arguments containing control characters,
+// for RejectControl.
+func controlErrors(args []string) []*ParseError {
+	var errs []*ParseError
+	for _, arg := range args {
+		if strings.IndexFunc(arg, unicode.IsControl) != -1 {
+			errs = append(errs, &ParseError{
+				Message:    "argument contains a control character",
+				Opt:        q(arg),
+				Unexpected: q(arg),
+				Expected:   "printable characters only",
+				kind:       ErrInvalidArgument,
+			})
+		}
+	}
+	return errs
+}
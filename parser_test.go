@@ -0,0 +1,233 @@
+package getopt
+
+import "testing"
+import "errors"
+import "io"
+import "reflect"
+
+func Test_Parser_bundle(t *testing.T) {
+	p, err := NewParser([]string{"-abc", "-x", "fizzy", "bears"}, "abcx:", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []OptArg{
+		{Option: "-a", Index: 0},
+		{Option: "-b", Index: 0},
+		{Option: "-c", Index: 0},
+		{Option: "-x", Argument: "fizzy", HasArgument: true, Index: 1},
+	}
+	for _, exp := range expected {
+		optarg, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if optarg != exp {
+			t.Log("got", optarg)
+			t.Log("expected", exp)
+			t.Fatal("recieved wrong option")
+		}
+	}
+	if _, err := p.Next(); err != io.EOF {
+		t.Log(err)
+		t.Fatal("expected io.EOF")
+	}
+	if _, err := p.Next(); err != io.EOF {
+		t.Log(err)
+		t.Fatal("expected io.EOF again")
+	}
+	if !reflect.DeepEqual(p.Args(), []string{"bears"}) {
+		t.Log("got", p.Args())
+		t.Fatal("recieved wrong leftovers")
+	}
+}
+
+func Test_Parser_resume(t *testing.T) {
+	spec, err := Compile("vo:", []string{"verbose"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := spec.NewParser([]string{"-yv", "-o", "--verbose", "fizzy"})
+	var opts []string
+	var errs []string
+	for {
+		optarg, err := p.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			errorQA(t, err)
+			errs = append(errs, err.(*ParseError).Opt)
+			continue
+		}
+		opts = append(opts, optarg.Option)
+	}
+	if !reflect.DeepEqual(opts, []string{"-v", "--verbose"}) {
+		t.Log("got", opts)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(errs, []string{"-y", "-o"}) {
+		t.Log("got", errs)
+		t.Fatal("recieved wrong errors")
+	}
+	if !reflect.DeepEqual(p.Args(), []string{"fizzy"}) {
+		t.Log("got", p.Args())
+		t.Fatal("recieved wrong leftovers")
+	}
+}
+
+func Test_Parser_compileError(t *testing.T) {
+	_, err := NewParser(nil, "vv", nil)
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func Test_Parser_bundlePosition(t *testing.T) {
+	_, _, err := GetOptSafe([]string{"-abxc"}, "abc", nil)
+	errorQA(t, err)
+	eparse, ok := err.(*ParseError)
+	if !ok || eparse.Opt != "-x" || eparse.Token != "-abxc" || eparse.Offset != 3 {
+		t.Log("got", eparse)
+		t.Fatal("expected the error to locate -x in the bundle")
+	}
+	if marked := eparse.Token[:eparse.Offset] + "^" + eparse.Token[eparse.Offset:]; marked != "-ab^xc" {
+		t.Log("got", marked)
+		t.Fatal("recieved wrong position")
+	}
+
+	_, _, err = GetOptSafe([]string{"-a", "-ä"}, "a", nil)
+	if eparse := err.(*ParseError); eparse.Token != "-ä" || eparse.Offset != 1 {
+		t.Log("got", eparse)
+		t.Fatal("recieved wrong position")
+	}
+	_, _, err = GetOptSafe([]string{"-äx"}, "ä", nil)
+	if eparse := err.(*ParseError); eparse.Opt != "-x" || eparse.Offset != 3 {
+		t.Log("got", eparse)
+		t.Fatal("expected a byte offset")
+	}
+
+	// The options preceding the unknown one are returned by a Parser.
+	p, err := NewParser([]string{"-abx"}, "ab", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opts []string
+	for {
+		optarg, err := p.Next()
+		if err != nil {
+			if err == io.EOF {
+				t.Fatal("expected an error")
+			}
+			break
+		}
+		opts = append(opts, optarg.Option)
+	}
+	if !reflect.DeepEqual(opts, []string{"-a", "-b"}) {
+		t.Log("got", opts)
+		t.Fatal("recieved wrong options")
+	}
+}
+
+func Test_Parser_OptArg_OptOpt(t *testing.T) {
+	p, err := NewParser(
+		[]string{"-a", "-b", "fizzy", "-x", "--wizard", "-b"}, "ab:", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type step struct {
+		option string
+		optarg string
+		optopt rune
+	}
+	var steps []step
+	for {
+		optarg, err := p.Next()
+		if err == io.EOF {
+			break
+		}
+		steps = append(steps, step{optarg.Option, p.OptArg(), p.OptOpt()})
+	}
+	expected := []step{
+		{"-a", "", 0},
+		{"-b", "fizzy", 0},
+		{"", "", 'x'},
+		{"", "", 0},
+		{"", "", 'b'},
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Log("got", steps)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong steps")
+	}
+}
+
+func Test_Parser_aliases(t *testing.T) {
+	args := []string{"-vofizzy", "--output", "bears", "--verbose", "-o", "x"}
+	p, err := NewParser(args, "", []string{"verbose|v", "output|o="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opts []OptArg
+	for {
+		optarg, err := p.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, optarg)
+	}
+	expected := []OptArg{
+		{Option: "--verbose", Index: 0},
+		{Option: "--output", Argument: "fizzy", HasArgument: true, Index: 0},
+		{Option: "--output", Argument: "bears", HasArgument: true, Index: 1},
+		{Option: "--verbose", Index: 3},
+		{Option: "--output", Argument: "x", HasArgument: true, Index: 4},
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Log("got", opts)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if args[opts[4].Index] != "-o" {
+		t.Log("got", args[opts[4].Index])
+		t.Fatal("expected the spelling to be found at Index")
+	}
+}
+
+func Test_Parser_abbrevResume(t *testing.T) {
+	spec, err := Compile("", []string{"verbose", "version", "color=?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.AllowAbbrev = true
+	p := spec.NewParser([]string{"--ver", "--verb", "--col=auto", "--co"})
+	var opts []OptArg
+	var errs []error
+	for {
+		optarg, err := p.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			errorQA(t, err)
+			errs = append(errs, err)
+			continue
+		}
+		opts = append(opts, optarg)
+	}
+	expected := []OptArg{
+		{Option: "--verbose", Index: 1},
+		{Option: "--color", Argument: "auto", HasArgument: true, Index: 2},
+		{Option: "--color", Index: 3},
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Log("got", opts)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrAmbiguousOption) ||
+		errs[0].Error() != "option is ambiguous (could be --verbose, --version): --ver" {
+		t.Log("got", errs)
+		t.Fatal("expected an ambiguous option error")
+	}
+}
@@ -0,0 +1,90 @@
+package getopt
+
+import "os"
+import "path/filepath"
+import "strings"
+
+// ExpandResponseFiles replaces each "@file" argument with the
+// arguments listed in the file, one per line, as GCC and Java tools
+// do; this allows passing command lines exceeding the limits of the
+// operating system. Call it on the arguments before parsing them:
+//
+//	args, err := getopt.ExpandResponseFiles(os.Args[1:])
+//	...
+//	args, opts, err := getopt.GetOpt(args, "vo:", nil)
+//
+// Leading and trailing whitespace is trimmed from each line; blank
+// lines, and lines starting with "#", are skipped. There is no
+// quoting: a line is taken as one argument, spaces and all. A file
+// may refer to other response files, relative to the working
+// directory; a file referring back to itself (directly or not) is a
+// ParseError, as is a file which cannot be read. A lone "@" is left
+// as it is, and so are the arguments following a "--" terminator,
+// whether it is given on the command line, or in a response file.
+func ExpandResponseFiles(args []string) ([]string, error) {
+	expanded, _, err := expandResponseFiles(args, nil)
+	return expanded, err
+}
+
+// expandResponseFiles expands args, which were read from the
+// response files on the stack (outermost first). It reports whether a
+// "--" terminator was found, after which nothing is expanded, even in
+// the files (or the arguments) which refer to this one.
+func expandResponseFiles(args []string, stack []string) ([]string, bool, error) {
+	var expanded []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(expanded, args[i:]...), true, nil
+		}
+		if len(arg) < 2 || arg[0] != '@' {
+			expanded = append(expanded, arg)
+			continue
+		}
+		path, err := filepath.Abs(arg[1:])
+		if err != nil {
+			return nil, false, responseError(arg, err)
+		}
+		for _, open := range stack {
+			if open == path {
+				return nil, false, &ParseError{
+					Message:    "response file refers to itself",
+					Opt:        arg,
+					Unexpected: q(arg),
+					Expected:   "a response file not already being read",
+					kind:       ErrInvalidArgument,
+				}
+			}
+		}
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, responseError(arg, err)
+		}
+		var lines []string
+		for _, line := range strings.Split(string(contents), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				lines = append(lines, line)
+			}
+		}
+		lines, terminated, err := expandResponseFiles(lines, append(stack, path))
+		if err != nil {
+			return nil, false, err
+		}
+		expanded = append(expanded, lines...)
+		if terminated {
+			return append(expanded, args[i+1:]...), true, nil
+		}
+	}
+	return expanded, false, nil
+}
+
+func responseError(arg string, err error) *ParseError {
+	return &ParseError{
+		Message:    "response file cannot be read",
+		Opt:        arg,
+		Unexpected: err.Error(),
+		Expected:   "a readable file",
+		Err:        err,
+		kind:       ErrInvalidArgument,
+	}
+}
@@ -0,0 +1,72 @@
+package getopt
+
+import "testing"
+import "errors"
+import "os"
+import "path/filepath"
+import "reflect"
+
+func Test_ExpandResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	inner := write("inner.rsp", "-x\r\n  two words  \n")
+	outer := write("outer.rsp", "# options\n-v\n\n@"+inner+"\n--output=a b\n")
+	args, err := ExpandResponseFiles(
+		[]string{"-a", "@" + outer, "@", "fizzy", "--", "@" + outer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"-a", "-v", "-x", "two words", "--output=a b", "@", "fizzy",
+		"--", "@" + outer,
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Log("got", args)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong args")
+	}
+
+	loop := filepath.Join(dir, "loop.rsp")
+	write("loop.rsp", "-v\n@"+write("back.rsp", "@"+loop+"\n")+"\n")
+	for _, arg := range []string{"@" + loop, "@" + filepath.Join(dir, "missing")} {
+		_, err := ExpandResponseFiles([]string{arg})
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidArgument) {
+			t.Log("input", arg)
+			t.Log("got", err)
+			t.Fatal("expected an invalid argument error")
+		}
+	}
+	_, err = ExpandResponseFiles([]string{"@" + filepath.Join(dir, "missing")})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Log("got", err)
+		t.Fatal("expected the error to wrap os.ErrNotExist")
+	}
+}
+
+func Test_ExpandResponseFiles_terminator(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err := os.WriteFile(a, []byte("-a\n--\n@"+b+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("-b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args, err := ExpandResponseFiles([]string{"@" + a, "@" + b, "-c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"-a", "--", "@" + b, "@" + b, "-c"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Log("got", args)
+		t.Log("expected", expected)
+		t.Fatal("expected nothing to be expanded after the terminator")
+	}
+}
@@ -0,0 +1,184 @@
+package getopt
+
+import "fmt"
+import "strconv"
+import "strings"
+import "unicode"
+import "unicode/utf8"
+
+// Result holds everything GetOptResult has made of an argument list:
+// the recognized options, and the remaining arguments, sorted into
+// buckets according to why they were not consumed as options.
+//
+// Which buckets get populated depends on the Config:
+//
+//   - Positionals is always populated, with the operands (the
+//     arguments which are not options, nor arguments to options).
+//   - AfterTerminator is always populated, with the arguments which
+//     followed the "--" terminator, if there was one.
+//   - Unrecognized is only populated in the Passthrough mode; without
+//     it, an unrecognized option is a ParseError.
+//
+// Args returns the union of all buckets, in their original order.
+type Result struct {
+	// Options are the recognized options, in the order in which they
+	// were encountered. Their helper methods, such as Has or Get, can
+	// be called directly on the Result.
+	Options
+
+	// Warnings describe any corrections made to the arguments
+	// while parsing them, in a form suitable for displaying to the
+	// user. There can only be any with certain Config options, such
+	// as NormalizeDashes.
+	Warnings []string
+
+	spec         *Spec
+	parent       *Result
+	args         []string
+	positionals  []string
+	terminated   []string
+	unrecognized []string
+}
+
+// Args returns all arguments which were not consumed as options, in
+// their original order. This is the same list as the leftovers
+// returned by GetOpt, and is suitable e.g. for passing through to
+// another program.
+func (r *Result) Args() []string { return r.args }
+
+// Positionals returns the operands: the arguments which were neither
+// options, nor arguments to options, and did not follow the "--"
+// terminator.
+func (r *Result) Positionals() []string { return r.positionals }
+
+// AfterTerminator returns the arguments which followed the "--"
+// terminator, or nil if there was none. The terminator itself is not
+// included.
+func (r *Result) AfterTerminator() []string { return r.terminated }
+
+// Unrecognized returns the options which were not recognized, but
+// tolerated because of the Passthrough mode.
+func (r *Result) Unrecognized() []string { return r.unrecognized }
+
+// EachLeftover calls fn for each of the arguments returned by Args,
+// in order, until fn returns false. Its signature matches that of
+// iter.Seq, so with Go 1.23 or later it can be used in a for-range
+// loop:
+//
+//	for arg := range r.EachLeftover {
+//	    ...
+//	}
+//
+// Unless some of the arguments had to be picked out from among the
+// options (e.g. in the Permute mode), the leftovers are not copied:
+// they are backed by the original argument list, same as the
+// leftovers returned by GetOpt. This keeps memory use down for long
+// lists of arguments, such as file names.
+func (r *Result) EachLeftover(fn func(string) bool) {
+	for _, arg := range r.args {
+		if !fn(arg) {
+			return
+		}
+	}
+}
+
+func (r *Result) positional(args ...string) {
+	r.args = extend(r.args, args)
+	r.positionals = extend(r.positionals, args)
+}
+
+func (r *Result) terminate(args []string) {
+	r.args = extend(r.args, args)
+	r.terminated = args
+}
+
+// extend appends args to list, or if the list is empty, returns args
+// as they are, to avoid copying the argument list. The capacity is
+// clipped, so that appending more can never overwrite the original.
+func extend(list, args []string) []string {
+	if len(list) == 0 {
+		return args[:len(args):len(args)]
+	}
+	return append(list, args...)
+}
+
+func (r *Result) unrecognize(opt string) {
+	r.args = append(r.args, opt)
+	r.unrecognized = append(r.unrecognized, opt)
+}
+
+func (r *Result) warn(format string, a ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, a...))
+}
+
+// Environ renders the recognized options as environment variable
+// assignments, in the "NAME=value" form expected by os/exec.Cmd.Env,
+// e.g. for passing the options on to a child process.
+//
+// The variable name is made from the option by stripping the leading
+// dashes, converting it to upper case, and replacing any character
+// other than a letter or a digit with an underscore "_"; then
+// prepending the prefix and an underscore, unless the prefix is
+// empty. With the prefix "PROG", "--dry-run" becomes PROG_DRY_RUN,
+// and "-v" becomes PROG_V. Note that "-v" and "-V" thus map to the
+// same name.
+//
+// An option given without an argument has the value "1", or the
+// number of times it was given, if more than once (so "-vvv" gives
+// PROG_V=3). An option given with an argument has the value of that
+// argument; if it was given more than once, the last one wins. The
+// variables are listed in the order of the first appearance of their
+// option.
+func (r *Result) Environ(prefix string) []string {
+	var names []string
+	values := make(map[string]string)
+	counts := make(map[string]int)
+	for _, o := range r.Options {
+		name := envName(prefix, o.Option)
+		if _, has := values[name]; !has {
+			names = append(names, name)
+		}
+		if o.HasArgument {
+			values[name] = o.Argument
+		} else {
+			counts[name]++
+			values[name] = strconv.Itoa(counts[name])
+		}
+	}
+	env := make([]string, len(names))
+	for i, name := range names {
+		env[i] = name + "=" + values[name]
+	}
+	return env
+}
+
+func envName(prefix, opt string) string {
+	name := strings.Map(func(c rune) rune {
+		if c < utf8.RuneSelf && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			return unicode.ToUpper(c)
+		}
+		return '_'
+	}, strings.TrimLeft(opt, "-"))
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// Strict returns a ParseError if any unrecognized options have been
+// tolerated because of the Passthrough mode, same as if they were
+// parsed without it. This allows a program to parse leniently at
+// first (e.g. to pick out a few options of interest), and insist on
+// every option being recognized later on.
+func (r *Result) Strict() error {
+	if len(r.unrecognized) == 0 {
+		return nil
+	}
+	return &ParseError{
+		Message:    "option not recognized",
+		Opt:        r.unrecognized[0],
+		Unexpected: q(r.unrecognized[0]),
+		Expected:   "a short or a long option",
+		kind:       ErrUnknownOption,
+	}
+}
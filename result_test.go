@@ -0,0 +1,710 @@
+package getopt
+
+import "testing"
+import "errors"
+import "reflect"
+import "strings"
+
+func Test_Result_positionals(t *testing.T) {
+	r, err := GetOptResult(
+		[]string{"-h", "fizzy", "-v", "bears"}, "hv", nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"fizzy", "-v", "bears"}
+	if !reflect.DeepEqual(r.Positionals(), expected) {
+		t.Log("got", r.Positionals())
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong positionals")
+	}
+	if !reflect.DeepEqual(r.Args(), expected) {
+		t.Log("got", r.Args())
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong args")
+	}
+	if r.AfterTerminator() != nil || r.Unrecognized() != nil {
+		t.Fatal("expected only positionals")
+	}
+}
+
+func Test_Result_afterTerminator(t *testing.T) {
+	r, err := GetOptResult(
+		[]string{"-h", "--", "-v", "bears"}, "hv", nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"-v", "bears"}
+	if !reflect.DeepEqual(r.AfterTerminator(), expected) {
+		t.Log("got", r.AfterTerminator())
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong arguments after terminator")
+	}
+	if !reflect.DeepEqual(r.Args(), expected) {
+		t.Log("got", r.Args())
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong args")
+	}
+	if r.Positionals() != nil || r.Unrecognized() != nil {
+		t.Fatal("expected only arguments after terminator")
+	}
+}
+
+func Test_Result_unrecognized(t *testing.T) {
+	r, err := GetOptResult(
+		[]string{"-hxv", "--wizard", "-h", "fizzy", "--", "bears"},
+		"hv", []string{"help"}, Config{Passthrough: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "-h", Index: 0},
+		{Option: "-v", Index: 0},
+		{Option: "-h", Index: 2},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	expectedUnrecognized := []string{"-x", "--wizard"}
+	if !reflect.DeepEqual(r.Unrecognized(), expectedUnrecognized) {
+		t.Log("got", r.Unrecognized())
+		t.Log("expected", expectedUnrecognized)
+		t.Fatal("recieved wrong unrecognized options")
+	}
+	expectedPositionals := []string{"fizzy", "--", "bears"}
+	if !reflect.DeepEqual(r.Positionals(), expectedPositionals) {
+		t.Log("got", r.Positionals())
+		t.Log("expected", expectedPositionals)
+		t.Fatal("recieved wrong positionals")
+	}
+	expectedArgs := []string{"-x", "--wizard", "fizzy", "--", "bears"}
+	if !reflect.DeepEqual(r.Args(), expectedArgs) {
+		t.Log("got", r.Args())
+		t.Log("expected", expectedArgs)
+		t.Fatal("recieved wrong args")
+	}
+}
+
+func Test_Result_unrecognizedWithoutPassthrough(t *testing.T) {
+	_, err := GetOptResult(
+		[]string{"-hxv"}, "hv", nil, Config{})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func Test_Result_requireEquals(t *testing.T) {
+	long := []string{"output=", "verbose"}
+	config := Config{RequireEquals: true}
+	r, err := GetOptResult(
+		[]string{"--output=a.out", "--output=", "fizzy"}, "", long, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--output", Argument: "a.out", HasArgument: true, Index: 0},
+		{Option: "--output", HasArgument: true, Index: 1},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	for _, input := range [][]string{
+		{"--output", "fizzy"},
+		{"--verbose", "--output"},
+	} {
+		_, err := GetOptResult(input, "", long, config)
+		errorQA(t, err)
+		if err == nil {
+			t.Log("input", input)
+			t.Fatal("expected an error")
+		}
+		if !strings.Contains(err.Error(), "--output=VALUE") {
+			t.Log(err)
+			t.Fatal("expected the error to suggest --output=VALUE")
+		}
+	}
+}
+
+func Test_Result_permute_long(t *testing.T) {
+	unsetPOSIXLY(t)
+	input := []string{"file", "--verbose", "other", "--out", "x", "-", "last"}
+	r, err := GetOptResult(input, "", []string{"verbose", "out="},
+		Config{Permute: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--verbose", Index: 1},
+		{Option: "--out", Argument: "x", HasArgument: true, Index: 3},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	expectedArgs := []string{"file", "other", "-", "last"}
+	if !reflect.DeepEqual(r.Args(), expectedArgs) {
+		t.Log("got", r.Args())
+		t.Log("expected", expectedArgs)
+		t.Fatal("recieved wrong args")
+	}
+}
+
+func Test_Result_permute(t *testing.T) {
+	unsetPOSIXLY(t)
+	input := []string{"fizzy", "-v", "bears", "-x", "are", "so", "--", "-h"}
+	r, err := GetOptResult(input, "hvx:", nil, Config{Permute: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "-v", Index: 1},
+		{Option: "-x", Argument: "are", HasArgument: true, Index: 3},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	expectedPositionals := []string{"fizzy", "bears", "so"}
+	if !reflect.DeepEqual(r.Positionals(), expectedPositionals) {
+		t.Log("got", r.Positionals())
+		t.Log("expected", expectedPositionals)
+		t.Fatal("recieved wrong positionals")
+	}
+	expectedArgs := []string{"fizzy", "bears", "so", "-h"}
+	if !reflect.DeepEqual(r.Args(), expectedArgs) {
+		t.Log("got", r.Args())
+		t.Log("expected", expectedArgs)
+		t.Fatal("recieved wrong args")
+	}
+
+	r, err = GetOptResult(input, "hvx:", nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Options) != 0 || !reflect.DeepEqual(r.Args(), input) {
+		t.Log("got", r.Options, r.Args())
+		t.Fatal("expected parsing to stop at the first operand")
+	}
+}
+
+func Test_Result_normalizeDashes(t *testing.T) {
+	input := []string{"–v", "—x", "—", "––help", "fizzy"}
+	r, err := GetOptResult(
+		input, "vx:", []string{"help"}, Config{NormalizeDashes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "-v", Index: 0},
+		{Option: "-x", Argument: "—", HasArgument: true, Index: 1},
+		{Option: "--help", Index: 3},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Args(), []string{"fizzy"}) {
+		t.Log("got", r.Args())
+		t.Fatal("recieved wrong args")
+	}
+	expectedWarnings := []string{
+		`interpreted "–v" as "-v"`,
+		`interpreted "—x" as "-x"`,
+		`interpreted "––help" as "--help"`,
+	}
+	if !reflect.DeepEqual(r.Warnings, expectedWarnings) {
+		t.Log("got", r.Warnings)
+		t.Log("expected", expectedWarnings)
+		t.Fatal("recieved wrong warnings")
+	}
+
+	_, err = GetOptResult(input, "vx:", []string{"help"}, Config{})
+	errorQA(t, err)
+	if err != nil {
+		t.Fatal("expected dashes to be left alone")
+	}
+}
+
+func Test_Result_environ(t *testing.T) {
+	r, err := GetOptResult(
+		[]string{
+			"-vv", "--dry-run", "-o", "a.out", "-I/a", "--output=b.out",
+			"-v", "-I", "/b",
+		},
+		"vo:I:", []string{"dry-run", "output="}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"PROG_V=3",
+		"PROG_DRY_RUN=1",
+		"PROG_O=a.out",
+		"PROG_I=/b",
+		"PROG_OUTPUT=b.out",
+	}
+	if env := r.Environ("PROG"); !reflect.DeepEqual(env, expected) {
+		t.Log("got", env)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong environment")
+	}
+	expected = []string{"V=3", "DRY_RUN=1", "O=a.out", "I=/b", "OUTPUT=b.out"}
+	if env := r.Environ(""); !reflect.DeepEqual(env, expected) {
+		t.Log("got", env)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong environment")
+	}
+}
+
+func Test_Result_strict(t *testing.T) {
+	r, err := GetOptResult(
+		[]string{"-h", "fizzy"}, "h", nil, Config{Passthrough: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Strict(); err != nil {
+		t.Fatal(err)
+	}
+	r, err = GetOptResult(
+		[]string{"-h", "--wizard", "-x", "fizzy"}, "h", nil,
+		Config{Passthrough: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Strict()
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if err.Error() != "option not recognized: --wizard" {
+		t.Log(err)
+		t.Fatal("expected the error to name the first unrecognized option")
+	}
+}
+
+func Test_Result_collectErrors(t *testing.T) {
+	unsetPOSIXLY(t)
+	input := []string{"-vxy", "--wizard", "-o", "--verbose", "fizzy", "-o"}
+	_, err := GetOptResult(input, "vo:", []string{"verbose"}, Config{})
+	if _, ok := err.(*ParseError); !ok {
+		t.Log(err)
+		t.Fatal("expected a single *ParseError")
+	}
+
+	_, err = GetOptResult(input, "vo:", []string{"verbose"},
+		Config{CollectErrors: true, Permute: true})
+	errs, ok := err.(ParseErrors)
+	if !ok {
+		t.Log(err)
+		t.Fatal("expected ParseErrors")
+	}
+	var opts []string
+	for _, err := range errs {
+		errorQA(t, err)
+		opts = append(opts, err.Opt)
+	}
+	expected := []string{"-x", "-y", "--wizard", "-o", "-o"}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Log("got", opts)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong errors")
+	}
+	if len(strings.Split(err.Error(), "\n")) != len(expected) {
+		t.Log(err)
+		t.Fatal("expected one error per line")
+	}
+	var eparse *ParseError
+	if !errors.As(err, &eparse) || eparse.Opt != "-x" {
+		t.Log(eparse)
+		t.Fatal("expected errors.As to find the first *ParseError")
+	}
+	// Same as errors.Is and errors.As do before Go 1.20, which do not
+	// follow Unwrap returning a list.
+	eparse = nil
+	if !errs.As(&eparse) || eparse.Opt != "-x" {
+		t.Log(eparse)
+		t.Fatal("expected ParseErrors.As to find the first *ParseError")
+	}
+	if !errs.Is(ErrUnknownOption) || !errs.Is(ErrMissingArgument) ||
+		errs.Is(ErrAmbiguousOption) {
+		t.Log(err)
+		t.Fatal("expected ParseErrors.Is to match the kinds of all errors")
+	}
+
+	r, err := GetOptResult([]string{"-v", "fizzy"}, "vo:", nil,
+		Config{CollectErrors: true})
+	if err != nil || len(r.Options) != 1 {
+		t.Log(r, err)
+		t.Fatal("expected no errors")
+	}
+}
+
+func Test_Result_EachLeftover(t *testing.T) {
+	input := []string{"-h", "fizzy", "bears", "are", "so", "tasty"}
+	for _, config := range []Config{{}, {Permute: true}} {
+		r, err := GetOptResult(input, "h", nil, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		r.EachLeftover(func(arg string) bool {
+			got = append(got, arg)
+			return true
+		})
+		if !reflect.DeepEqual(got, r.Args()) {
+			t.Log("got", got)
+			t.Log("expected", r.Args())
+			t.Fatal("EachLeftover disagrees with Args")
+		}
+		got = nil
+		r.EachLeftover(func(arg string) bool {
+			got = append(got, arg)
+			return len(got) < 2
+		})
+		if !reflect.DeepEqual(got, []string{"fizzy", "bears"}) {
+			t.Log("got", got)
+			t.Fatal("EachLeftover should stop when fn returns false")
+		}
+	}
+
+	r, err := GetOptResult(input, "h", nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &r.Args()[0] != &input[1] {
+		t.Fatal("expected the leftovers to be backed by the input")
+	}
+}
+
+func Test_Result_numericOperands(t *testing.T) {
+	unsetPOSIXLY(t)
+	config := Config{NumericOperands: true, Permute: true}
+	r, err := GetOptResult(
+		[]string{"-v", "-5", "fizzy", "-1"}, "v1", nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{{Option: "-v", Index: 0}, {Option: "-1", Index: 3}}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	expectedArgs := []string{"-5", "fizzy"}
+	if !reflect.DeepEqual(r.Args(), expectedArgs) {
+		t.Log("got", r.Args())
+		t.Log("expected", expectedArgs)
+		t.Fatal("recieved wrong args")
+	}
+
+	// "-12" declares "-1" but not "-2", so it is parsed as options.
+	_, err = GetOptResult([]string{"-12"}, "v1", nil, config)
+	errorQA(t, err)
+	if err == nil || err.(*ParseError).Opt != "-2" {
+		t.Log(err)
+		t.Fatal("expected -2 not to be recognized")
+	}
+
+	r, err = GetOptResult([]string{"-5", "-v"}, "v", nil,
+		Config{NumericOperands: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Options) != 0 || !reflect.DeepEqual(r.Args(), []string{"-5", "-v"}) {
+		t.Log("got", r.Options, r.Args())
+		t.Fatal("expected parsing to stop at the numeric operand")
+	}
+
+	_, err = GetOptResult([]string{"-5"}, "v", nil, Config{})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error without NumericOperands")
+	}
+}
+
+func Test_Result_rejectControl(t *testing.T) {
+	input := []string{"-v", "-o", "fizzy\x00bears", "-\x00", "so"}
+	_, err := GetOptResult(input, "vo:", nil, Config{})
+	errorQA(t, err)
+	if !errors.Is(err, ErrUnknownOption) {
+		t.Log(err)
+		t.Fatal("expected NUL to be an unrecognized option character")
+	}
+	r, err := GetOptResult(input[:3], "vo:", nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg, _ := r.Get("-o"); arg != "fizzy\x00bears" {
+		t.Log("got", r.Options)
+		t.Fatal("expected NUL to be an ordinary character in an argument")
+	}
+
+	_, err = GetOptResult(input, "vo:", nil,
+		Config{RejectControl: true, CollectErrors: true})
+	errs, ok := err.(ParseErrors)
+	if !ok || len(errs) != 3 {
+		t.Log(err)
+		t.Fatal("expected two control character errors and an unknown option")
+	}
+	for _, err := range errs[:2] {
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidArgument) {
+			t.Log(err)
+			t.Fatal("expected an invalid argument error")
+		}
+	}
+	expected := `argument contains a control character: "fizzy\x00bears"`
+	if errs[0].Error() != expected {
+		t.Log("got", errs[0])
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong error")
+	}
+}
+
+func Test_Result_trimSpace(t *testing.T) {
+	input := []string{" -v", "fizzy"}
+	r, err := GetOptResult(input, "v", nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Options) != 0 || !reflect.DeepEqual(r.Args(), input) {
+		t.Log("got", r.Options, r.Args())
+		t.Fatal("expected \" -v\" to be an operand")
+	}
+
+	input = []string{" -v", "-x", " bears ", "\t–v\n", " fizzy "}
+	r, err = GetOptResult(input, "vx:", nil,
+		Config{TrimSpace: true, NormalizeDashes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "-v", Index: 0},
+		{Option: "-x", Argument: " bears ", HasArgument: true, Index: 1},
+		{Option: "-v", Index: 3},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Args(), []string{" fizzy "}) {
+		t.Log("got", r.Args())
+		t.Fatal("expected the operand to be left alone")
+	}
+	expectedWarnings := []string{
+		`interpreted " -v" as "-v"`,
+		`interpreted "\t–v\n" as "-v"`,
+	}
+	if !reflect.DeepEqual(r.Warnings, expectedWarnings) {
+		t.Log("got", r.Warnings)
+		t.Log("expected", expectedWarnings)
+		t.Fatal("recieved wrong warnings")
+	}
+}
+
+func Test_Result_ignoreCase(t *testing.T) {
+	long := []string{"help", "output="}
+	input := []string{"--Help", "--help", "--HELP", "--OutPut=A.OUT", "-h"}
+	r, err := GetOptResult(input[:4], "h", long, Config{IgnoreCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--help", Index: 0},
+		{Option: "--help", Index: 1},
+		{Option: "--help", Index: 2},
+		{Option: "--output", Argument: "A.OUT", HasArgument: true, Index: 3},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	_, err = GetOptResult([]string{"-H"}, "h", long, Config{IgnoreCase: true})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected short options to be case sensitive")
+	}
+	for _, arg := range []string{"--Help", "--HELP"} {
+		_, err := GetOptResult([]string{arg}, "", long, Config{})
+		errorQA(t, err)
+		if err == nil {
+			t.Log("input", arg)
+			t.Fatal("expected an error without IgnoreCase")
+		}
+	}
+
+	_, err = GetOptResult([]string{"--COLOR"}, "",
+		[]string{"color", "Color"}, Config{IgnoreCase: true})
+	errorQA(t, err)
+	if !errors.Is(err, ErrAmbiguousOption) {
+		t.Log(err)
+		t.Fatal("expected an ambiguous option error")
+	}
+}
+
+func Test_Result_singleDashLong(t *testing.T) {
+	long := []string{"name=", "maxdepth=", "print"}
+	config := Config{SingleDashLong: true}
+	r, err := GetOptResult(
+		[]string{"-name", "x", "-maxdepth=2", "-n", "--print", "-print", "-np"},
+		"np", long, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--name", Argument: "x", HasArgument: true, Index: 0},
+		{Option: "--maxdepth", Argument: "2", HasArgument: true, Index: 2},
+		{Option: "-n", Index: 3},
+		{Option: "--print", Index: 4},
+		{Option: "--print", Index: 5},
+		{Option: "-n", Index: 6},
+		{Option: "-p", Index: 6},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+
+	r, err = GetOptResult([]string{"-name"}, "name", long, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Options) != 4 || r.Options[0].Option != "-n" {
+		t.Log("got", r.Options)
+		t.Fatal("expected a bundle without SingleDashLong")
+	}
+}
+
+func Test_Result_collapseDashes(t *testing.T) {
+	input := []string{"---flag", "----flag"}
+	_, err := GetOptResult(input, "", []string{"flag"}, Config{})
+	errorQA(t, err)
+	if !errors.Is(err, ErrUnknownOption) {
+		t.Log(err)
+		t.Fatal("expected ---flag not to be recognized")
+	}
+
+	r, err := GetOptResult(input, "", []string{"flag"},
+		Config{CollapseDashes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{{Option: "--flag", Index: 0}, {Option: "--flag", Index: 1}}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	expectedWarnings := []string{
+		`interpreted "---flag" as "--flag"`,
+		`interpreted "----flag" as "--flag"`,
+	}
+	if !reflect.DeepEqual(r.Warnings, expectedWarnings) {
+		t.Log("got", r.Warnings)
+		t.Log("expected", expectedWarnings)
+		t.Fatal("recieved wrong warnings")
+	}
+	_, err = GetOptResult([]string{"---"}, "", []string{"flag"},
+		Config{CollapseDashes: true})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected --- to stay unrecognized")
+	}
+}
+
+func Test_Result_assign(t *testing.T) {
+	long := []string{"key=", "color=?", "flag"}
+	config := Config{Assign: ':'}
+	r, err := GetOptResult(
+		[]string{"--key:value", "--color:a=b", "--key", "x=y", "--color"},
+		"", long, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--key", Argument: "value", HasArgument: true, Index: 0},
+		{Option: "--color", Argument: "a=b", HasArgument: true, Index: 1},
+		{Option: "--key", Argument: "x=y", HasArgument: true, Index: 2},
+		{Option: "--color", Index: 4},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	for _, input := range [][]string{{"--key=value"}, {"--flag:x"}} {
+		_, err := GetOptResult(input, "", long, config)
+		errorQA(t, err)
+		if err == nil {
+			t.Log("input", input)
+			t.Fatal("expected an error")
+		}
+	}
+
+	spec, err := Compile("", long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Config = config
+	expectedUsage := "Usage: prog [--key ARG] [--color[:ARG]] [--flag] [ARG...]"
+	if usage := spec.Usage("prog"); usage != expectedUsage {
+		t.Log("got", usage)
+		t.Log("expected", expectedUsage)
+		t.Fatal("recieved wrong usage")
+	}
+}
+
+func Test_Result_repair(t *testing.T) {
+	args := []string{"--flag", "=fizzy", "-", "x", "--color", "=", "-", "y", "-"}
+	r, err := GetOptResult(args, "x", []string{"flag=", "color=?"},
+		Config{Repair: true, Permute: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--flag", Argument: "fizzy", HasArgument: true, Index: 0},
+		{Option: "-x", Index: 2},
+		{Option: "--color", Argument: "", HasArgument: true, Index: 4},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if !reflect.DeepEqual(r.Positionals(), []string{"-", "y", "-"}) {
+		t.Log("got", r.Positionals())
+		t.Fatal("recieved wrong positionals")
+	}
+	warnings := []string{
+		`interpreted "--flag" "=fizzy" as "--flag=fizzy"`,
+		`interpreted "-" "x" as "-x"`,
+		`interpreted "--color" "=" as "--color="`,
+	}
+	if !reflect.DeepEqual(r.Warnings, warnings) {
+		t.Log("got", r.Warnings)
+		t.Log("expected", warnings)
+		t.Fatal("recieved wrong warnings")
+	}
+
+	// Without Repair, the same arguments mean something else.
+	r, err = GetOptResult(args[:4], "x", []string{"flag="}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = Options{
+		{Option: "--flag", Argument: "=fizzy", HasArgument: true, Index: 0},
+	}
+	if !reflect.DeepEqual(r.Options, expected) || r.Warnings != nil {
+		t.Log("got", r.Options, r.Warnings)
+		t.Fatal("expected no repairs")
+	}
+}
@@ -0,0 +1,117 @@
+package getopt
+
+import "fmt"
+import "strings"
+
+// Rule checks the parsed options and the leftover arguments, and
+// returns a ParseError describing the problem, if there is one.
+// Rules are built with functions such as Required or MaxOperands, and
+// checked together with Require.
+type Rule func(opts Options, leftovers []string) error
+
+// Rules is a set of rules, checked in order by Require.
+type Rules []Rule
+
+// Require checks the options and the leftovers returned by GetOpt
+// against the rules, and returns the error from the first rule which
+// is broken, or nil. For example:
+//
+//	err := getopt.Require(opts, args, getopt.Rules{
+//	    getopt.Required("--output"),
+//	    getopt.Exclusive("-v", "-q"),
+//	    getopt.MaxOperands(1),
+//	})
+func Require(opts []OptArg, leftovers []string, rules Rules) error {
+	for _, rule := range rules {
+		if err := rule(opts, leftovers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Required is a Rule requiring each of the named options to be given.
+func Required(names ...string) Rule {
+	return func(opts Options, leftovers []string) error {
+		for _, name := range names {
+			if !opts.Has(name) {
+				return &ParseError{
+					Message:  "option is required",
+					Opt:      name,
+					Expected: name,
+					kind:     ErrMissingOption,
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Exclusive is a Rule allowing at most one of the named options to be
+// given.
+func Exclusive(names ...string) Rule {
+	return func(opts Options, leftovers []string) error {
+		var present []string
+		for _, name := range names {
+			if opts.Has(name) {
+				present = append(present, name)
+			}
+		}
+		if len(present) < 2 {
+			return nil
+		}
+		return &ParseError{
+			Message: fmt.Sprintf(
+				"option cannot be given together with %s", present[0]),
+			Opt:        present[1],
+			Unexpected: strings.Join(present, ", "),
+			Expected:   "at most one of " + strings.Join(names, ", "),
+			kind:       ErrConflictingOptions,
+		}
+	}
+}
+
+// AtLeastOne is a Rule requiring at least one of the named options to
+// be given.
+func AtLeastOne(names ...string) Rule {
+	return func(opts Options, leftovers []string) error {
+		for _, name := range names {
+			if opts.Has(name) {
+				return nil
+			}
+		}
+		return &ParseError{
+			Message: fmt.Sprintf(
+				"one of the options is required: %s",
+				strings.Join(names, ", ")),
+			Expected: "at least one of " + strings.Join(names, ", "),
+			kind:     ErrMissingOption,
+		}
+	}
+}
+
+// AllOrNone is a Rule requiring either all of the named options to be
+// given, or none of them; see Options.AllOrNone.
+func AllOrNone(names ...string) Rule {
+	return func(opts Options, leftovers []string) error {
+		return opts.AllOrNone(names...)
+	}
+}
+
+// MaxOperands is a Rule allowing at most n leftover arguments; e.g.
+// MaxOperands(0) for a program which takes no operands.
+func MaxOperands(n int) Rule {
+	return func(opts Options, leftovers []string) error {
+		if len(leftovers) <= n {
+			return nil
+		}
+		return &ParseError{
+			Message: fmt.Sprintf(
+				"too many operands (at most %d allowed)", n),
+			Opt:        leftovers[n],
+			Unexpected: q(leftovers[n]),
+			Expected:   fmt.Sprintf("at most %d operands", n),
+			kind:       ErrTooManyOperands,
+		}
+	}
+}
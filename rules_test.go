@@ -0,0 +1,73 @@
+package getopt
+
+import "testing"
+import "errors"
+
+// The options of ls(1), as in examples/ls.go.
+var lsShortopts = "aAbBcCdDfFgGhHI:klLmnNopqQrRsStT:uUvw:xXZ1"
+var lsLongopts = []string{
+	"all", "almost-all", "color=?", "format=", "human-readable", "si",
+	"sort=", "time=", "width=", "help", "version",
+}
+
+func Test_Rules_ls(t *testing.T) {
+	rules := Rules{
+		Exclusive("-1", "-C", "-l", "-m", "-x", "--format"),
+		Exclusive("--human-readable", "-h", "--si"),
+		AllOrNone("--sort", "--time"),
+		MaxOperands(2),
+	}
+	for _, tc := range []struct {
+		input []string
+		kind  error
+	}{
+		{[]string{"-la", "--color", "fizzy"}, nil},
+		{[]string{"--sort=time", "--time=ctime", "a", "b"}, nil},
+		{[]string{"-l1"}, ErrConflictingOptions},
+		{[]string{"-x", "--format=long"}, ErrConflictingOptions},
+		{[]string{"-h", "--si"}, ErrConflictingOptions},
+		{[]string{"--sort=time"}, ErrMissingOption},
+		{[]string{"a", "b", "c"}, ErrTooManyOperands},
+	} {
+		args, opts, err := GetOptSafe(tc.input, lsShortopts, lsLongopts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Require(opts, args, rules)
+		errorQA(t, err)
+		if tc.kind == nil && err != nil || !errors.Is(err, tc.kind) {
+			t.Log("input", tc.input)
+			t.Log("got", err)
+			t.Log("expected", tc.kind)
+			t.Fatal("recieved wrong error")
+		}
+	}
+}
+
+func Test_Rules_messages(t *testing.T) {
+	args, opts, err := GetOptSafe(
+		[]string{"-l1", "a", "b"}, lsShortopts, lsLongopts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		rule     Rule
+		expected string
+	}{
+		{Required("--format"), "option is required: --format"},
+		{Exclusive("-1", "-l"), "option cannot be given together with -1: -l"},
+		{AtLeastOne("--all", "-a"), "one of the options is required: --all, -a"},
+		{MaxOperands(1), "too many operands (at most 1 allowed): b"},
+	} {
+		err := Require(opts, args, Rules{tc.rule})
+		errorQA(t, err)
+		if err == nil || err.Error() != tc.expected {
+			t.Log("got", err)
+			t.Log("expected", tc.expected)
+			t.Fatal("recieved wrong error")
+		}
+	}
+	if err := Require(opts, args, Rules{AtLeastOne("-a", "-l")}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,656 @@
+package getopt
+
+import "errors"
+import "fmt"
+import "io"
+import "os"
+import "strconv"
+import "strings"
+
+// lookup returns the name under which a declared option is reported
+// (resolving any alias), and its arity. It panics if the option was
+// not declared.
+func (s *Spec) lookup(name string) (string, arity) {
+	if long, has := s.aliases[name]; has {
+		name = long
+	}
+	if ar, has := s.longs[name]; has {
+		return name, ar
+	} else if ar, has := s.shorts[name]; has {
+		return name, ar
+	}
+	panic(&ParseError{
+		Message:       "option not declared",
+		Opt:           name,
+		Unexpected:    q(name),
+		notUsersFault: true,
+		kind:          ErrInvalidSpec,
+	})
+}
+
+// RestAsString makes the named options capture the remainder of the
+// argument list as their argument: everything following the option
+// (including anything attached to it, as in "-mfirst" or
+// "--message=first") is joined into one string, separated with single
+// spaces, and option processing stops. The arguments are joined
+// verbatim: there is no quoting, and "--" is captured like any other
+// argument. This suits options like "--message rest of the line".
+//
+// If nothing follows the option, its argument is empty, and
+// OptArg.HasArgument is false; unless the option requires an
+// argument, in which case it's a ParseError.
+func (s *Spec) RestAsString(names ...string) *Spec {
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		s.rest[name] = true
+	}
+	return s
+}
+
+// LiteralSwitch makes the named options end option processing, same
+// as "--" does, but under a self-documenting name such as
+// "--literal". The option itself is reported as usual (along with
+// its argument, if it takes one), and any further options in the
+// same bundle are still parsed; the following arguments are then
+// returned in Result.AfterTerminator.
+func (s *Spec) LiteralSwitch(names ...string) *Spec {
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		s.literal[name] = true
+	}
+	return s
+}
+
+// Abbreviatable lets the named long options be abbreviated, same as
+// with AllowAbbrev, while the others must be given in full. This keeps
+// e.g. "--delete-everything" from being picked out of a prefix, while
+// still allowing "--verb" for "--verbose". An abbreviation is only
+// matched against the marked options; AllowAbbrev, if set, marks them
+// all.
+//
+// Only long options can be abbreviated; naming a short option panics,
+// same as naming an undeclared one.
+func (s *Spec) Abbreviatable(names ...string) *Spec {
+	marked := make(map[string]bool)
+	if s.abbrevs != nil {
+		for _, name := range s.abbrevs.sorted {
+			marked[name] = true
+		}
+	}
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		if _, has := s.longs[name]; !has {
+			panic(&ParseError{
+				Message:       "option cannot be abbreviated",
+				Opt:           name,
+				Unexpected:    q(name),
+				Expected:      "a long option",
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			})
+		}
+		marked[name] = true
+	}
+	var ordered []string
+	for _, name := range s.longNames {
+		if marked[name] {
+			ordered = append(ordered, name)
+		}
+	}
+	s.abbrevs = newPrefixIndex(ordered)
+	return s
+}
+
+// Negatable declares the negations of the named long options, such
+// as "--no-color" for "--color", so that a flag enabled by default
+// (or in a configuration file) can be turned off on the command line.
+// The negation is reported as an option of its own, e.g. "--no-color";
+// use Options.Flag to find out which of the two was given last.
+//
+// Only long options which take no argument can be negated; naming
+// any other option panics, same as naming an undeclared one, or one
+// whose negation is already declared in longopts.
+func (s *Spec) Negatable(names ...string) *Spec {
+	for _, name := range names {
+		name, ar := s.lookup(name)
+		if _, has := s.longs[name]; !has || ar != argNone {
+			panic(&ParseError{
+				Message:       "option cannot be negated",
+				Opt:           name,
+				Unexpected:    q(name),
+				Expected:      "a long option without an argument",
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			})
+		}
+		neg := "--no-" + name[2:]
+		if s.negated[neg] == name {
+			continue
+		} else if _, has := s.longs[neg]; has {
+			panic(&ParseError{
+				Message:       "option specified more than once",
+				Opt:           neg,
+				Unexpected:    q(neg),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			})
+		}
+		s.negated[neg] = name
+		s.longs[neg] = argNone
+		s.longNames = append(s.longNames, neg)
+		lower := strings.ToLower(neg)
+		s.folded[lower] = append(s.folded[lower], neg)
+	}
+	s.index = newPrefixIndex(s.longNames)
+	return s
+}
+
+// abbrevIndex returns the index of the long options which may be
+// abbreviated, or nil if none may.
+func (s *Spec) abbrevIndex() *prefixIndex {
+	if s.AllowAbbrev {
+		return s.index
+	}
+	return s.abbrevs
+}
+
+// assign returns the Config.Assign character, as a string.
+func (s *Spec) assign() string {
+	if s.Assign == 0 {
+		return "="
+	}
+	return string(s.Assign)
+}
+
+// declared reports whether arg names a declared long option exactly,
+// ignoring any attached argument.
+func (s *Spec) declared(arg string) bool {
+	if i := strings.Index(arg, s.assign()); i != -1 {
+		arg = arg[:i]
+	}
+	_, has := s.longs[arg]
+	return has
+}
+
+// Section puts the named options in a section with the given title,
+// such as "Output control", for grouping them in help texts.
+func (s *Spec) Section(title string, names ...string) *Spec {
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		s.sections[name] = title
+	}
+	return s
+}
+
+// Description sets a one-line description of the named option, for
+// use in help texts.
+func (s *Spec) Description(name, text string) *Spec {
+	name, _ = s.lookup(name)
+	s.descriptions[name] = text
+	return s
+}
+
+// Metavar names the argument of the named option in help texts, such
+// as "FILE" in "[-o FILE]"; the default is "ARG".
+func (s *Spec) Metavar(name, metavar string) *Spec {
+	name, _ = s.lookup(name)
+	s.metavars[name] = metavar
+	return s
+}
+
+// Operands names the operands in help texts, such as "FILE" in
+// "[FILE...]"; the default is "ARG".
+func (s *Spec) Operands(metavar string) *Spec {
+	s.operands = metavar
+	return s
+}
+
+// CountMax limits how many times the named option can be given, as
+// for a verbosity level counted by repeating "-v": with a max of 3,
+// "-vvv" is fine, but a fourth "-v" (bundled or not, or spelled
+// "--verbose" if it is an alias) is a ParseError naming the limit.
+// Use Options.Count to read the level.
+func (s *Spec) CountMax(name string, max int) *Spec {
+	name, _ = s.lookup(name)
+	s.countMax[name] = max
+	return s
+}
+
+// Required marks the named options as required: if any of them is
+// missing once the arguments are parsed with ParseResult (or Parse),
+// the result is a ParseError listing all the missing options, as in
+// "options are required: --cert, --key". An option counts as given if
+// it has a value from the environment or a default as well (see
+// Result.Lookup).
+//
+// A Parser does not check the required options, as it can't tell
+// when the caller is done.
+func (s *Spec) Required(names ...string) *Spec {
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		s.required = append(s.required, name)
+	}
+	return s
+}
+
+// Requires declares that the named option, if given, requires the
+// other options to be given as well; as in "--key" requiring
+// "--cert". Otherwise, once the arguments are parsed with ParseResult
+// (or Parse), the result is a ParseError naming the missing options,
+// as in "option must be given with --cert: --key". Same as with
+// Required, a required option also counts as given if it has a value
+// from the environment or a default.
+func (s *Spec) Requires(name string, required ...string) *Spec {
+	name, _ = s.lookup(name)
+	dep := dependency{opt: name}
+	for _, req := range required {
+		req, _ = s.lookup(req)
+		dep.requires = append(dep.requires, req)
+	}
+	s.requires = append(s.requires, dep)
+	return s
+}
+
+// Exclusive declares that at most one of the named options may be
+// given, as in "--quiet" and "--verbose"; otherwise, once the
+// arguments are parsed with ParseResult (or Parse), the result is a
+// ParseError, same as from the Exclusive rule. The options are also
+// kept apart by the completion scripts (see PrintCompletion).
+func (s *Spec) Exclusive(names ...string) *Spec {
+	var set []string
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		set = append(set, name)
+	}
+	s.exclusive = append(s.exclusive, set)
+	return s
+}
+
+// dependency is an option requiring other options, for Requires.
+type dependency struct {
+	opt      string
+	requires []string
+}
+
+// check checks the parsed options against the constraints declared by
+// Required, Requires and Exclusive.
+func (s *Spec) check(r *Result) []*ParseError {
+	errs := s.checkRequired(r)
+	for _, set := range s.exclusive {
+		if err := Exclusive(set...)(r.Options, nil); err != nil {
+			errs = append(errs, err.(*ParseError))
+		}
+	}
+	for _, dep := range s.requires {
+		if !r.Has(dep.opt) {
+			continue
+		}
+		var missing []string
+		for _, req := range dep.requires {
+			if _, src := r.Lookup(req); src == Unset {
+				missing = append(missing, req)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, &ParseError{
+				Message: fmt.Sprintf(
+					"option must be given with %s", strings.Join(missing, ", ")),
+				Opt:        dep.opt,
+				Unexpected: "missing " + strings.Join(missing, ", "),
+				Expected:   strings.Join(dep.requires, ", "),
+				kind:       ErrMissingOption,
+			})
+		}
+	}
+	return errs
+}
+
+// checkRequired checks that the options marked with Required were
+// given.
+func (s *Spec) checkRequired(r *Result) []*ParseError {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, name := range s.required {
+		if _, src := r.Lookup(name); src == Unset && !seen[name] {
+			missing = append(missing, name)
+		}
+		seen[name] = true
+	}
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return []*ParseError{{
+			Message:  "option is required",
+			Opt:      missing[0],
+			Expected: missing[0],
+			kind:     ErrMissingOption,
+		}}
+	}
+	return []*ParseError{{
+		Message:  "options are required",
+		Opt:      strings.Join(missing, ", "),
+		Expected: "all of " + strings.Join(missing, ", "),
+		kind:     ErrMissingOption,
+	}}
+}
+
+// validator checks the argument of an option, and may normalize it.
+type validator func(optarg *OptArg) error
+
+// ValidDuration makes the named options only accept arguments which
+// are durations, as understood by time.ParseDuration, such as "90s"
+// or "1m30s"; anything else is a ParseError. The arguments are also
+// normalized, so that equal durations are spelled the same: both
+// "--timeout=60s" and "--timeout=1m" are reported as "1m0s".
+func (s *Spec) ValidDuration(names ...string) *Spec {
+	return s.valid(names, "duration", func(opt, arg string) (string, error) {
+		d, err := parseDuration(opt, arg)
+		return d.String(), err
+	})
+}
+
+// ValidInt makes the named options only accept arguments which are
+// integers, such as "42" or "-5"; anything else is a ParseError. The
+// arguments are normalized, same as by ValidDuration: "+05" is
+// reported as "5".
+func (s *Spec) ValidInt(names ...string) *Spec {
+	return s.valid(names, "int", func(opt, arg string) (string, error) {
+		n, err := parseInt(opt, arg, 64)
+		return strconv.FormatInt(n, 10), err
+	})
+}
+
+// ValidUint makes the named options only accept arguments which are
+// non-negative integers, normalized same as by ValidInt.
+func (s *Spec) ValidUint(names ...string) *Spec {
+	return s.valid(names, "uint", func(opt, arg string) (string, error) {
+		n, err := parseUint(opt, arg, 64)
+		return strconv.FormatUint(n, 10), err
+	})
+}
+
+// ValidFloat makes the named options only accept arguments which are
+// numbers, as understood by strconv.ParseFloat, such as "1.5" or
+// "2e3". The arguments are normalized, so that "2e3" is reported as
+// "2000".
+func (s *Spec) ValidFloat(names ...string) *Spec {
+	return s.valid(names, "float", func(opt, arg string) (string, error) {
+		f, err := parseFloat(opt, arg, 64)
+		return strconv.FormatFloat(f, 'g', -1, 64), err
+	})
+}
+
+// ValidBool makes the named options only accept arguments which are
+// booleans, as understood by strconv.ParseBool, such as "true", "0"
+// or "F". The arguments are normalized to "true" or "false".
+func (s *Spec) ValidBool(names ...string) *Spec {
+	return s.valid(names, "bool", func(opt, arg string) (string, error) {
+		b, err := parseBool(opt, arg)
+		return strconv.FormatBool(b), err
+	})
+}
+
+// valid makes the named options only accept the arguments which parse
+// can make sense of, and replaces them with the normalized form
+// returned by parse. An option given without an argument is left
+// alone. typ names the type of the arguments, for Describe.
+func (s *Spec) valid(
+	names []string,
+	typ string,
+	parse func(opt, arg string) (string, error),
+) *Spec {
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		s.types[name] = typ
+		s.validators[name] = append(s.validators[name], func(optarg *OptArg) error {
+			if !optarg.HasArgument {
+				return nil
+			}
+			arg, err := parse(optarg.Option, optarg.Argument)
+			if err != nil {
+				return err
+			}
+			optarg.Argument = arg
+			return nil
+		})
+	}
+	return s
+}
+
+// Default records the default value of the argument of the named
+// option, for use in help texts. When the option is not given, the
+// default is returned by Result.Lookup (which tells it apart from a
+// value given on the command line), and stored in the destinations
+// bound to the option, such as with StringVar or CompileStruct.
+func (s *Spec) Default(name, value string) *Spec {
+	name, _ = s.lookup(name)
+	s.defaults[name] = value
+	return s
+}
+
+// ConfigValue records the value of the named option read from a
+// configuration file, such as by the configfile package. When the
+// option is not given, nor set in the environment (see Env), the value
+// takes precedence over the Default; it is returned by Result.Lookup,
+// and stored in the destinations bound to the option, same as the
+// Default. Unlike the Default, it is not shown in help texts.
+func (s *Spec) ConfigValue(name, value string) *Spec {
+	name, _ = s.lookup(name)
+	s.configured[name] = value
+	return s
+}
+
+// Env names the environment variable, such as PROG_OUTPUT, which
+// provides the value of the named option when it is not given on the
+// command line. The command line takes precedence over the
+// environment, which takes precedence over a ConfigValue, and then
+// the Default. The value is
+// returned by Result.Lookup, and stored in the destinations bound to
+// the option, such as with StringVar; it is not among the parsed
+// Options.
+func (s *Spec) Env(name, variable string) *Spec {
+	name, _ = s.lookup(name)
+	s.env[name] = variable
+	return s
+}
+
+// EnvPrefix names an environment variable for each long option, same
+// as Env, unless already named; made from the prefix and the option,
+// same as by Result.Environ: with the prefix "PROG", "--dry-run" falls
+// back to PROG_DRY_RUN. A flag can then be set with e.g. PROG_VERBOSE=1,
+// as passed on by Environ.
+func (s *Spec) EnvPrefix(prefix string) *Spec {
+	for _, name := range s.longNames {
+		if _, has := s.env[name]; has {
+			continue
+		} else if _, has := s.negated[name]; has {
+			continue
+		}
+		s.env[name] = envName(prefix, name)
+	}
+	return s
+}
+
+// Hidden marks the named options as hidden from help texts, e.g. for
+// deprecated or internal options. They are still parsed as usual.
+func (s *Spec) Hidden(names ...string) *Spec {
+	for _, name := range names {
+		name, _ = s.lookup(name)
+		s.hidden[name] = true
+	}
+	return s
+}
+
+// WithValues limits the arguments of the named option to the given
+// values, such as "always", "never" or "auto" for "--color"; any
+// other argument is a ParseError listing the values. The values are
+// also offered by Parser.CompletionValues, for completing the
+// argument in a shell.
+func (s *Spec) WithValues(name string, values ...string) *Spec {
+	name, _ = s.lookup(name)
+	s.values[name] = values
+	s.validators[name] = append(s.validators[name], func(optarg *OptArg) error {
+		if !optarg.HasArgument {
+			return nil
+		}
+		for _, value := range values {
+			if optarg.Argument == value {
+				return nil
+			}
+		}
+		return &ParseError{
+			Message: fmt.Sprintf(
+				"option expects one of: %s", strings.Join(values, ", ")),
+			Opt:        optarg.Option,
+			Unexpected: q(optarg.Argument),
+			Expected:   "one of " + strings.Join(values, ", "),
+			kind:       ErrInvalidArgument,
+		}
+	})
+	return s
+}
+
+// StdinSentinel makes the named options read their argument from the
+// standard input (see Spec.Stdin), when it is given as sentinel; as
+// in "--password -", for passing secrets without exposing them in the
+// argument list. All of the input is read, and a single trailing
+// newline ("\n" or "\r\n") is trimmed; any other whitespace is kept.
+func (s *Spec) StdinSentinel(name, sentinel string) *Spec {
+	name, _ = s.lookup(name)
+	s.validators[name] = append(s.validators[name], func(optarg *OptArg) error {
+		if !optarg.HasArgument || optarg.Argument != sentinel {
+			return nil
+		}
+		stdin := s.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return &ParseError{
+				Message:    "option could not read its argument",
+				Opt:        optarg.Option,
+				Unexpected: err.Error(),
+				Expected:   "an argument on the standard input",
+				Err:        err,
+				kind:       ErrInvalidArgument,
+			}
+		}
+		arg := string(input)
+		if strings.HasSuffix(arg, "\r\n") {
+			arg = strings.TrimSuffix(arg, "\r\n")
+		} else {
+			arg = strings.TrimSuffix(arg, "\n")
+		}
+		optarg.Argument = arg
+		return nil
+	})
+	return s
+}
+
+// hint fills in the help text of err, for Config.Hints.
+func (s *Spec) hint(err *ParseError) {
+	if errors.Is(err, ErrUnknownOption) {
+		err.Hint = s.suggest(err.Opt)
+		return
+	}
+	name := err.Opt
+	if long, has := s.aliases[name]; has {
+		name = long
+	}
+	err.Section = s.sections[name]
+	err.Description = s.descriptions[name]
+	var hint []string
+	for _, text := range []string{err.Section, err.Description} {
+		if text != "" {
+			hint = append(hint, text)
+		}
+	}
+	err.Hint = strings.Join(hint, ": ")
+}
+
+// suggest lists the declared long options sharing the longest prefix
+// with an unrecognized one, grouped by their section.
+func (s *Spec) suggest(opt string) string {
+	if !strings.HasPrefix(opt, "--") {
+		return ""
+	}
+	if i := strings.Index(opt, s.assign()); i != -1 {
+		opt = opt[:i]
+	}
+	var names []string
+	for n := len(opt); n > len("--") && len(names) == 0; n-- {
+		names, _ = s.index.match(opt[:n])
+	}
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("did you mean %s?", names[0])
+	}
+	var titles []string
+	groups := make(map[string][]string)
+	for _, name := range names {
+		title := s.sections[name]
+		if _, has := groups[title]; !has {
+			titles = append(titles, title)
+		}
+		groups[title] = append(groups[title], name)
+	}
+	parts := make([]string, len(titles))
+	for i, title := range titles {
+		parts[i] = strings.Join(groups[title], ", ")
+		if title != "" {
+			parts[i] = title + ": " + parts[i]
+		}
+	}
+	return fmt.Sprintf("did you mean one of: %s?", strings.Join(parts, "; "))
+}
+
+// numeric reports whether arg is a negative number, for
+// Config.NumericOperands: a "-" followed by digits, none of which is
+// declared as a short option.
+func (s *Spec) numeric(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	for _, c := range arg[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+		if _, declared := s.shorts["-"+string(c)]; declared {
+			return false
+		}
+	}
+	return true
+}
+
+// fold replaces the name of the long option in arg with its declared
+// spelling, for Config.IgnoreCase. Only the name is folded, and not
+// any attached argument.
+func (s *Spec) fold(arg string) (string, error) {
+	name, rest := arg, ""
+	if i := strings.Index(arg, s.assign()); i != -1 {
+		name, rest = arg[:i], arg[i:]
+	}
+	if _, has := s.longs[name]; has {
+		return arg, nil
+	}
+	switch names := s.folded[strings.ToLower(name)]; len(names) {
+	case 0:
+		return arg, nil
+	case 1:
+		return names[0] + rest, nil
+	default:
+		return "", &ParseError{
+			Message: fmt.Sprintf(
+				"option is ambiguous (could be %s)",
+				strings.Join(names, ", ")),
+			Opt:        name,
+			Unexpected: q(name),
+			Expected:   "one of " + strings.Join(names, ", "),
+			kind:       ErrAmbiguousOption,
+		}
+	}
+}
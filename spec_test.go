@@ -0,0 +1,638 @@
+package getopt
+
+import "testing"
+import "errors"
+import "reflect"
+import "strings"
+
+func Test_Spec_undeclaredPanics(t *testing.T) {
+	spec, err := Compile("v", []string{"message|m="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := recover()
+		if eparse, ok := err.(*ParseError); ok && eparse.notUsersFault {
+			t.Log("panicked as expected")
+			return
+		}
+		t.Log("RestAsString was expected to panic")
+		t.Fail()
+	}()
+	spec.RestAsString("--wizard")
+}
+
+func Test_Spec_RestAsString(t *testing.T) {
+	spec, err := Compile("v", []string{"message|m=", "note"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.RestAsString("-m", "--note")
+	for _, tc := range []struct {
+		input   []string
+		optargs Options
+	}{
+		{
+			[]string{"-v", "--message", "rest", "of", "-v", "the line"},
+			Options{
+				{Option: "-v"},
+				{Option: "--message", Argument: "rest of -v the line",
+					HasArgument: true, Index: 1},
+			},
+		},
+		{
+			[]string{"--message=rest", "of", "the", "line"},
+			Options{
+				{Option: "--message", Argument: "rest of the line",
+					HasArgument: true},
+			},
+		},
+		{
+			[]string{"-vmrest", "of", "--", "line"},
+			Options{
+				{Option: "-v"},
+				{Option: "--message", Argument: "rest of -- line",
+					HasArgument: true},
+			},
+		},
+		{
+			[]string{"--note"},
+			Options{{Option: "--note"}},
+		},
+	} {
+		r, err := spec.ParseResult(tc.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(r.Options, tc.optargs) {
+			t.Log("input", tc.input)
+			t.Log("got", r.Options)
+			t.Log("expected", tc.optargs)
+			t.Fatal("recieved wrong options")
+		}
+		if len(r.Args()) != 0 {
+			t.Log("got", r.Args())
+			t.Fatal("expected no leftovers")
+		}
+	}
+	_, err = spec.ParseResult([]string{"-v", "-m"})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func Test_Spec_Abbreviatable(t *testing.T) {
+	spec, err := Compile("", []string{"verbose", "delete-everything", "del"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Abbreviatable("--verbose", "--delete-everything")
+	r, err := spec.ParseResult([]string{"--verb", "--del", "--delete-e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--verbose", Index: 0},
+		{Option: "--del", Index: 1},
+		{Option: "--delete-everything", Index: 2},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+
+	spec, err = Compile("", []string{"verbose", "delete-everything"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Abbreviatable("--verbose")
+	_, err = spec.ParseResult([]string{"--delete"})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an unmarked option not to match its prefix")
+	}
+
+	defer func() {
+		err := recover()
+		if eparse, ok := err.(*ParseError); ok && eparse.notUsersFault {
+			t.Log("panicked as expected")
+			return
+		}
+		t.Log("Abbreviatable was expected to panic")
+		t.Fail()
+	}()
+	spec, _ = Compile("v", nil)
+	spec.Abbreviatable("-v")
+}
+
+func Test_Spec_hints(t *testing.T) {
+	spec, err := Compile("", []string{
+		"format=", "form-feed", "width|w=", "file=",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Section("Output control", "--format", "--form-feed", "--width")
+	spec.Section("Input", "--file")
+	spec.Description("--width", "set the line width")
+	spec.Hints = true
+	for _, tc := range []struct {
+		input    []string
+		expected string
+	}{
+		{
+			[]string{"--fmt"},
+			"option not recognized: --fmt (did you mean one of: " +
+				"Output control: --format, --form-feed; Input: --file?)",
+		},
+		{
+			[]string{"--forx"},
+			"option not recognized: --forx (did you mean one of: " +
+				"Output control: --format, --form-feed?)",
+		},
+		{
+			[]string{"--wid"},
+			"option not recognized: --wid (did you mean --width?)",
+		},
+		{
+			[]string{"--xyz"},
+			"option not recognized: --xyz",
+		},
+		{
+			[]string{"-w"},
+			"option requires an argument: --width " +
+				"(Output control: set the line width)",
+		},
+		{
+			[]string{"--file"},
+			"option requires an argument: --file (Input)",
+		},
+	} {
+		_, err := spec.ParseResult(tc.input)
+		errorQA(t, err)
+		if err == nil || err.Error() != tc.expected {
+			t.Log("input", tc.input)
+			t.Log("got", err)
+			t.Log("expected", tc.expected)
+			t.Fatal("recieved wrong error")
+		}
+	}
+	_, err = spec.ParseResult([]string{"-w"})
+	if eparse := err.(*ParseError); eparse.Section != "Output control" ||
+		eparse.Description != "set the line width" {
+		t.Log("got", eparse.Section, eparse.Description)
+		t.Fatal("expected the error to carry the section and description")
+	}
+
+	spec.Hints = false
+	_, err = spec.ParseResult([]string{"--fmt"})
+	if err == nil || err.Error() != "option not recognized: --fmt" {
+		t.Log(err)
+		t.Fatal("expected no hint without Config.Hints")
+	}
+}
+
+func Test_Spec_ValidDuration(t *testing.T) {
+	spec, err := Compile("t:", []string{"timeout=", "delay="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.ValidDuration("--timeout", "-t")
+	r, err := spec.ParseResult(
+		[]string{"--timeout=60s", "-t1m", "--delay=soon"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--timeout", Argument: "1m0s", HasArgument: true, Index: 0},
+		{Option: "-t", Argument: "1m0s", HasArgument: true, Index: 1},
+		{Option: "--delay", Argument: "soon", HasArgument: true, Index: 2},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+
+	_, err = spec.ParseResult([]string{"--timeout", "soon"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Log(err)
+		t.Fatal("expected an invalid argument error")
+	}
+}
+
+func Test_Spec_ValidTypes(t *testing.T) {
+	spec, err := Compile("w:", []string{"uint=", "float=", "bool=?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.ValidInt("-w").ValidUint("--uint").ValidFloat("--float").
+		ValidBool("--bool")
+	r, err := spec.ParseResult([]string{
+		"-w+05", "--uint=007", "--float=2e3", "--bool=T", "--bool",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "-w", Argument: "5", HasArgument: true, Index: 0},
+		{Option: "--uint", Argument: "7", HasArgument: true, Index: 1},
+		{Option: "--float", Argument: "2000", HasArgument: true, Index: 2},
+		{Option: "--bool", Argument: "true", HasArgument: true, Index: 3},
+		{Option: "--bool", Index: 4},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+
+	for args, message := range map[string]string{
+		"-wabc":        "option expects an integer: -w",
+		"--uint=-1":    "option expects a non-negative integer: --uint",
+		"--float=half": "option expects a number: --float",
+		"--bool=maybe": "option expects a boolean: --bool",
+	} {
+		_, err := spec.ParseResult([]string{args})
+		errorQA(t, err)
+		if !errors.Is(err, ErrInvalidArgument) || err.Error() != message {
+			t.Log("input", args)
+			t.Log("got", err)
+			t.Log("expected", message)
+			t.Fatal("expected an invalid argument error")
+		}
+	}
+}
+
+func Test_Spec_LiteralSwitch(t *testing.T) {
+	spec, err := Compile("vL", []string{"literal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.LiteralSwitch("--literal", "-L")
+	r, err := spec.ParseResult(
+		[]string{"-v", "--literal", "-v", "--", "fizzy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{{Option: "-v", Index: 0}, {Option: "--literal", Index: 1}}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	expectedArgs := []string{"-v", "--", "fizzy"}
+	if !reflect.DeepEqual(r.AfterTerminator(), expectedArgs) ||
+		!reflect.DeepEqual(r.Args(), expectedArgs) {
+		t.Log("got", r.AfterTerminator(), r.Args())
+		t.Log("expected", expectedArgs)
+		t.Fatal("recieved wrong args")
+	}
+
+	r, err = spec.ParseResult([]string{"-Lv", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = Options{{Option: "-L", Index: 0}, {Option: "-v", Index: 0}}
+	if !reflect.DeepEqual(r.Options, expected) ||
+		!reflect.DeepEqual(r.Args(), []string{"-v"}) {
+		t.Log("got", r.Options, r.Args())
+		t.Fatal("expected the rest of the bundle to be parsed")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func Test_Spec_StdinSentinel(t *testing.T) {
+	spec, err := Compile("", []string{"password=", "user="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.StdinSentinel("--password", "-")
+	for _, tc := range []struct {
+		stdin    string
+		expected string
+	}{
+		{"hunter2\n", "hunter2"},
+		{"hunter2\r\n", "hunter2"},
+		{" hunter2 \n\n", " hunter2 \n"},
+		{"hunter2", "hunter2"},
+	} {
+		spec.Stdin = strings.NewReader(tc.stdin)
+		r, err := spec.ParseResult(
+			[]string{"--user", "-", "--password", "-"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if arg, _ := r.Get("--password"); arg != tc.expected {
+			t.Log("got", q(arg))
+			t.Log("expected", q(tc.expected))
+			t.Fatal("recieved wrong password")
+		}
+		if arg, _ := r.Get("--user"); arg != "-" {
+			t.Log("got", arg)
+			t.Fatal("expected --user to be left alone")
+		}
+	}
+
+	spec.Stdin = strings.NewReader("hunter2\n")
+	r, err := spec.ParseResult([]string{"--password=secret"})
+	if arg, _ := r.Get("--password"); err != nil || arg != "secret" {
+		t.Log("got", arg, err)
+		t.Fatal("expected the argument to be left alone")
+	}
+
+	spec.Stdin = failingReader{}
+	_, err = spec.ParseResult([]string{"--password", "-"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Log(err)
+		t.Fatal("expected an invalid argument error")
+	}
+}
+
+func Test_Spec_WithValues(t *testing.T) {
+	spec, err := Compile("", []string{"color|C=?", "format="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.WithValues("--color", "always", "never", "auto")
+	r, err := spec.ParseResult(
+		[]string{"--color=never", "--color", "-Cauto", "--format=x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Options) != 4 {
+		t.Log("got", r.Options)
+		t.Fatal("recieved wrong options")
+	}
+	_, err = spec.ParseResult([]string{"--color=sometimes"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Log(err)
+		t.Fatal("expected an invalid argument error")
+	}
+	expected := "option expects one of: always, never, auto: --color"
+	if err.Error() != expected {
+		t.Log("got", err)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong error")
+	}
+
+	p := spec.NewParser(nil)
+	if words := p.CompletionValues("-C", "a"); !reflect.DeepEqual(
+		words, []string{"always", "auto"}) {
+		t.Log("got", words)
+		t.Fatal("recieved wrong completion")
+	}
+	if words := p.CompletionValues("--color", ""); len(words) != 3 {
+		t.Log("got", words)
+		t.Fatal("expected all the values")
+	}
+	if words := p.CompletionValues("--format", ""); words != nil {
+		t.Log("got", words)
+		t.Fatal("expected no values")
+	}
+}
+
+func Test_Spec_RestAsString_terminator(t *testing.T) {
+	spec, err := Compile("v", []string{"exec="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.RestAsString("--exec")
+	r, err := spec.ParseResult([]string{"-v", "--exec", "cmd", "--", "arg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "-v", Index: 0},
+		{Option: "--exec", Argument: "cmd -- arg", HasArgument: true, Index: 1},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	if len(r.Args()) != 0 || r.AfterTerminator() != nil {
+		t.Log("got", r.Args(), r.AfterTerminator())
+		t.Fatal("expected -- to be captured, rather than terminate")
+	}
+
+	r, err = spec.ParseResult([]string{"--exec", "--", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg, _ := r.Get("--exec"); arg != "-- -v" {
+		t.Log("got", r.Options)
+		t.Fatal("expected a leading -- to be captured")
+	}
+}
+
+func Test_Spec_CountMax(t *testing.T) {
+	spec, err := Compile("q", []string{"verbose|v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.CountMax("-v", 3)
+	for _, args := range [][]string{
+		{"-vvv"},
+		{"-v", "-v", "-v"},
+		{"-vq", "--verbose", "-qv", "-q"},
+	} {
+		r, err := spec.ParseResult(args)
+		if err != nil {
+			t.Log("args", args)
+			t.Fatal(err)
+		}
+		if r.Count("--verbose") != 3 {
+			t.Log("args", args)
+			t.Log("got", r.Count("--verbose"))
+			t.Fatal("recieved wrong count")
+		}
+	}
+	for _, args := range [][]string{
+		{"-vvvv"},
+		{"-v", "-v", "-v", "-v"},
+		{"-vv", "--verbose", "-qv"},
+	} {
+		_, err := spec.ParseResult(args)
+		errorQA(t, err)
+		if !errors.Is(err, ErrRepeatedOption) ||
+			err.Error() != "option cannot be given more than 3 times: --verbose" {
+			t.Log("args", args)
+			t.Log(err)
+			t.Fatal("expected a repeated option error")
+		}
+	}
+}
+
+func Test_Spec_Negatable(t *testing.T) {
+	spec, err := Compile("v", []string{"color|c", "sync", "level="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Negatable("-c", "--sync").Negatable("--sync")
+	r, err := spec.ParseResult([]string{"-c", "--no-color", "--sync"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Options{
+		{Option: "--color", Index: 0},
+		{Option: "--no-color", Index: 1},
+		{Option: "--sync", Index: 2},
+	}
+	if !reflect.DeepEqual(r.Options, expected) {
+		t.Log("got", r.Options)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong options")
+	}
+	for name, exp := range map[string][2]bool{
+		"--color": {false, true},
+		"--sync":  {true, true},
+		"--level": {false, false},
+	} {
+		if on, given := r.Flag(name); on != exp[0] || given != exp[1] {
+			t.Log("option", name)
+			t.Log("got", on, given)
+			t.Fatal("recieved wrong flag")
+		}
+	}
+
+	spec.AllowAbbrev = true
+	r, err = spec.ParseResult([]string{"--no-s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if on, given := r.Flag("--sync"); on || !given {
+		t.Log("got", r.Options)
+		t.Fatal("expected an abbreviated negation")
+	}
+
+	usage := spec.Usage("prog")
+	if usage != "Usage: prog [-v] [--[no-]color] [--[no-]sync] [--level ARG] [ARG...]" {
+		t.Log("got", usage)
+		t.Fatal("recieved wrong usage")
+	}
+
+	for _, tc := range []struct {
+		longopts []string
+		name     string
+	}{
+		{[]string{"level="}, "--level"},
+		{[]string{"color|c"}, "-v"},
+		{[]string{"color", "no-color"}, "--color"},
+	} {
+		spec, err := Compile("v", tc.longopts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrInvalidSpec) {
+					t.Log("option", tc.name)
+					t.Log("got", err)
+					t.Fatal("expected a panic")
+				}
+			}()
+			spec.Negatable(tc.name)
+		}()
+	}
+}
+
+func Test_Spec_Required(t *testing.T) {
+	spec, err := Compile("v", []string{"cert|c=", "key=", "mode="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Required("-c", "--key").Required("--mode", "--cert").
+		Default("--mode", "fast")
+	_, _, err = spec.Parse([]string{"-c", "a.pem", "--key=a.key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for args, message := range map[string]string{
+		"-v":           "options are required: --cert, --key",
+		"--key=a.key":  "option is required: --cert",
+		"--cert=a.pem": "option is required: --key",
+	} {
+		_, _, err := spec.Parse([]string{args})
+		errorQA(t, err)
+		if !errors.Is(err, ErrMissingOption) || err.Error() != message {
+			t.Log("input", args)
+			t.Log("got", err)
+			t.Log("expected", message)
+			t.Fatal("expected a missing option error")
+		}
+	}
+
+	spec.CollectErrors = true
+	_, _, err = spec.Parse([]string{"-x"})
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 2 ||
+		!errors.Is(errs[1], ErrMissingOption) {
+		t.Log("got", err)
+		t.Fatal("expected the missing options to be collected")
+	}
+}
+
+func Test_Spec_Requires(t *testing.T) {
+	spec, err := Compile("vk:", []string{"cert=", "ca=", "key=", "mode="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Requires("-k", "--cert", "--ca").Requires("--key", "--mode").
+		Default("--mode", "fast")
+	for _, args := range [][]string{
+		{"-v"},
+		{"--cert=a.pem"},
+		{"-k", "a.key", "--cert=a.pem", "--ca=ca.pem"},
+	} {
+		if _, _, err := spec.Parse(args); err != nil {
+			t.Log("input", args)
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range []struct {
+		args    []string
+		message string
+	}{
+		{[]string{"-ka.key"}, "option must be given with --cert, --ca: -k"},
+		{[]string{"-ka.key", "--ca=ca.pem"}, "option must be given with --cert: -k"},
+	} {
+		_, _, err := spec.Parse(tc.args)
+		errorQA(t, err)
+		if !errors.Is(err, ErrMissingOption) || err.Error() != tc.message {
+			t.Log("input", tc.args)
+			t.Log("got", err)
+			t.Log("expected", tc.message)
+			t.Fatal("expected a missing option error")
+		}
+	}
+}
+
+func Test_Spec_Exclusive(t *testing.T) {
+	spec, err := Compile("vqx", []string{"verbose|V"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Exclusive("-q", "-V")
+	if _, _, err := spec.Parse([]string{"-qx"}); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = spec.Parse([]string{"-q", "-V"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrConflictingOptions) ||
+		err.Error() != "option cannot be given together with -q: --verbose" {
+		t.Log("got", err)
+		t.Fatal("expected conflicting options")
+	}
+}
@@ -0,0 +1,86 @@
+package getopt
+
+import "errors"
+import "io"
+
+// TokenKind tells what a Token stands for.
+type TokenKind int
+
+const (
+	// OperandToken is an argument which was not consumed as an
+	// option, in Token.Operand.
+	OperandToken TokenKind = iota
+
+	// OptionToken is a recognized option, in Token.OptArg.
+	OptionToken
+)
+
+// Token is either an option, or an operand, as returned by
+// GetOptTokens.
+type Token struct {
+	Kind    TokenKind
+	OptArg  OptArg
+	Operand string
+}
+
+// GetOptTokens works like GetOptResult, but returns the options and
+// the operands together, in the order in which they were given. This
+// is useful in the Permute mode, for programs which give meaning to
+// the relative order of options and operands, such as find(1) does
+// with "find . -name x -o -name y".
+//
+// Every argument which is not consumed as an option becomes an
+// OperandToken: the operands proper, as well as the arguments after
+// the "--" terminator (though not the terminator itself), and any
+// unrecognized options tolerated in the Passthrough mode; same as
+// what Result.Args returns.
+func GetOptTokens(
+	args []string,
+	shortopts string,
+	longopts []string,
+	config Config,
+) ([]Token, error) {
+	spec, err := Compile(shortopts, longopts)
+	if err != nil {
+		return nil, err
+	}
+	spec.Config = config
+	return spec.ParseTokens(args)
+}
+
+// ParseTokens works like GetOptTokens, but parses args according to
+// the compiled Spec (including its Config). Same as with ParseResult,
+// an option intercepted by the parser (such as with Version) stops it,
+// even in the CollectErrors mode.
+func (s *Spec) ParseTokens(args []string) ([]Token, error) {
+	p := s.NewParser(args)
+	var tokens []Token
+	var errs ParseErrors
+	seen := 0
+	for {
+		optarg, err := p.Next()
+		// Any leftovers found while looking for the next option
+		// came before it.
+		for _, arg := range p.r.args[seen:] {
+			tokens = append(tokens, Token{Kind: OperandToken, Operand: arg})
+		}
+		seen = len(p.r.args)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			var eparse *ParseError
+			if !s.CollectErrors || errors.Is(err, ErrVersion) ||
+				errors.Is(err, ErrCompletion) || !errors.As(err, &eparse) {
+				return nil, err
+			}
+			errs = append(errs, eparse)
+			continue
+		}
+		tokens = append(tokens, Token{Kind: OptionToken, OptArg: optarg})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return tokens, nil
+}
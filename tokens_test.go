@@ -0,0 +1,57 @@
+package getopt
+
+import "testing"
+import "errors"
+import "io"
+import "reflect"
+
+func Test_Tokens_interleaved(t *testing.T) {
+	unsetPOSIXLY(t)
+	tokens, err := GetOptTokens(
+		[]string{".", "-n", "x", "-o", "-n", "y", "-z", "--", "-o"},
+		"n:o", nil, Config{Permute: true, Passthrough: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Token{
+		{Kind: OperandToken, Operand: "."},
+		{Kind: OptionToken, OptArg: OptArg{
+			Option: "-n", Argument: "x", HasArgument: true, Index: 1}},
+		{Kind: OptionToken, OptArg: OptArg{Option: "-o", Index: 3}},
+		{Kind: OptionToken, OptArg: OptArg{
+			Option: "-n", Argument: "y", HasArgument: true, Index: 4}},
+		{Kind: OperandToken, Operand: "-z"},
+		{Kind: OperandToken, Operand: "-o"},
+	}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Log("got", tokens)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong tokens")
+	}
+}
+
+func Test_Tokens_error(t *testing.T) {
+	unsetPOSIXLY(t)
+	_, err := GetOptTokens([]string{"fizzy", "-x"}, "n:o", nil,
+		Config{Permute: true})
+	errorQA(t, err)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func Test_Tokens_intercepted(t *testing.T) {
+	spec, err := Compile("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Stdout = io.Discard
+	spec.CollectErrors = true
+	spec.Version("prog 1.0")
+	_, err = spec.ParseTokens([]string{"--version", "-z"})
+	errorQA(t, err)
+	if !errors.Is(err, ErrVersion) || errors.Is(err, ErrUnknownOption) {
+		t.Log("got", err)
+		t.Fatal("expected the parser to stop at --version")
+	}
+}
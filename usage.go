@@ -0,0 +1,114 @@
+package getopt
+
+import "strings"
+
+// Usage renders a conventional one-line synopsis of the command line
+// accepted by prog, such as:
+//
+//	Usage: prog [-hv] [-x ARG] [--flag ARG] [ARG...]
+//
+// The short options which take no argument are grouped in a single
+// bracketed cluster, followed by each of the short options which take
+// an argument, and then each of the long options, in the order of
+// their declaration; a short alias of a long option (as in
+// "output|o=") is left out, as the option is listed by its long name.
+// An optional argument is bracketed itself, as in
+// "[-C[ARG]]" or "[--color[=ARG]]"; so is the prefix of a negation
+// (see Spec.Negatable), as in "[--[no-]color]". The arguments and the
+// operands may be named with Spec.Metavar and Spec.Operands, as in
+// "[-o FILE] [FILE...]". The options marked Hidden are left out.
+//
+// Usage panics if there is a programming error in shortopts or
+// longopts, same as GetOpt. See also Spec.Usage.
+func Usage(prog string, shortopts string, longopts []string) string {
+	spec, err := Compile(shortopts, longopts)
+	if err != nil {
+		panic(err)
+	}
+	return spec.Usage(prog)
+}
+
+// Usage renders a one-line synopsis of the command line accepted by
+// prog, according to the Spec. See the Usage function for details.
+func (s *Spec) Usage(prog string) string {
+	return "Usage: " + s.synopsis(prog, "ARG", "ARG")
+}
+
+// POSIXUsage renders a synopsis of the command line accepted by prog,
+// in the format of the POSIX Utility Syntax Guidelines, such as:
+//
+//	prog [-hv] [-x option_argument] [-C[option_argument]] [operand...]
+//
+// The options are ordered the same as by Usage; any long options
+// follow the short ones, as in "[--flag option_argument]", although
+// the guidelines do not provide for them. Any names set with
+// Spec.Metavar or Spec.Operands are used instead.
+func (s *Spec) POSIXUsage(prog string) string {
+	return s.synopsis(prog, "option_argument", "operand")
+}
+
+func (s *Spec) synopsis(prog, arg, operands string) string {
+	if s.operands != "" {
+		operands = s.operands
+	}
+	items := append([]string{prog}, s.synopsisOptions(arg)...)
+	items = append(items, "["+operands+"...]")
+	return strings.Join(items, " ")
+}
+
+// synopsisOptions renders the options for synopsis.
+func (s *Spec) synopsisOptions(arg string) []string {
+	var items []string
+	var shorts []string
+	for _, opt := range s.shortNames {
+		if _, has := s.aliases[opt]; !has && !s.hidden[opt] {
+			shorts = append(shorts, opt)
+		}
+	}
+	flags := ""
+	for _, opt := range shorts {
+		if s.shorts[opt] == argNone {
+			flags += opt[1:]
+		}
+	}
+	if flags != "" {
+		items = append(items, "[-"+flags+"]")
+	}
+	for _, opt := range shorts {
+		switch s.shorts[opt] {
+		case argRequired:
+			items = append(items, "["+opt+" "+s.metavar(opt, arg)+"]")
+		case argOptional:
+			items = append(items, "["+opt+"["+s.metavar(opt, arg)+"]]")
+		}
+	}
+	for _, opt := range s.longNames {
+		if _, has := s.negated[opt]; has || s.hidden[opt] {
+			continue
+		}
+		switch s.longs[opt] {
+		case argNone:
+			if s.negated["--no-"+opt[2:]] == opt {
+				opt = "--[no-]" + opt[2:]
+			}
+			items = append(items, "["+opt+"]")
+		case argRequired:
+			items = append(items, "["+opt+" "+s.metavar(opt, arg)+"]")
+		case argOptional:
+			items = append(items, "["+opt+"["+s.assign()+s.metavar(opt, arg)+"]]")
+		}
+	}
+	return items
+}
+
+// metavar returns the name of the argument of the option, as set with
+// Spec.Metavar (resolving any alias), or def.
+func (s *Spec) metavar(opt, def string) string {
+	if long, has := s.aliases[opt]; has {
+		opt = long
+	}
+	if metavar, has := s.metavars[opt]; has {
+		return metavar
+	}
+	return def
+}
@@ -0,0 +1,82 @@
+package getopt
+
+import "testing"
+
+func Test_Usage(t *testing.T) {
+	for _, tc := range []struct {
+		shortopts string
+		longopts  []string
+		expected  string
+	}{
+		{
+			"hvx:", []string{"flag="},
+			"Usage: prog [-hv] [-x ARG] [--flag ARG] [ARG...]",
+		},
+		{
+			"", nil,
+			"Usage: prog [ARG...]",
+		},
+		{
+			"aC::b", []string{"help|h", "color=?", "output|o="},
+			"Usage: prog [-ab] [-C[ARG]] [--help] " +
+				"[--color[=ARG]] [--output ARG] [ARG...]",
+		},
+	} {
+		usage := Usage("prog", tc.shortopts, tc.longopts)
+		if usage != tc.expected {
+			t.Log("got", usage)
+			t.Log("expected", tc.expected)
+			t.Fatal("recieved wrong usage")
+		}
+	}
+}
+
+func Test_Usage_POSIX(t *testing.T) {
+	spec, err := Compile("aAbBcCdDfFgGhHI:klLmnNopqQrRsStT:uUvw:xXZ1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "ls [-aAbBcCdDfFgGhHklLmnNopqQrRsStuUvxXZ1] " +
+		"[-I option_argument] [-T option_argument] [-w option_argument] " +
+		"[operand...]"
+	if usage := spec.POSIXUsage("ls"); usage != expected {
+		t.Log("got", usage)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong usage")
+	}
+
+	spec, err = Compile("vC::", []string{"color=?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "prog [-v] [-C[option_argument]] " +
+		"[--color[=option_argument]] [operand...]"
+	if usage := spec.POSIXUsage("prog"); usage != expected {
+		t.Log("got", usage)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong usage")
+	}
+}
+
+func Test_Spec_Usage_metavars(t *testing.T) {
+	spec, err := Compile("abcI:", []string{"output|o=", "color=?", "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Metavar("-I", "DIR").Metavar("-o", "FILE").Metavar("--color", "WHEN").
+		Operands("FILE").Hidden("--debug")
+	expected := "Usage: prog [-abc] [-I DIR] [--output FILE] " +
+		"[--color[=WHEN]] [FILE...]"
+	if usage := spec.Usage("prog"); usage != expected {
+		t.Log("got", usage)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong usage")
+	}
+	expected = "prog [-abc] [-I DIR] [--output FILE] " +
+		"[--color[=WHEN]] [FILE...]"
+	if usage := spec.POSIXUsage("prog"); usage != expected {
+		t.Log("got", usage)
+		t.Log("expected", expected)
+		t.Fatal("recieved wrong usage")
+	}
+}
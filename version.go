@@ -0,0 +1,90 @@
+package getopt
+
+import "fmt"
+import "io"
+import "os"
+import "strings"
+
+// Version makes the parser intercept the -V and --version options:
+// rather than returning either, it prints version to Stdout, followed
+// by the build metadata (such as a commit hash, or a build date), one
+// item per line, and returns a ParseError matching ErrVersion, as
+// Python's argparse does. The program should then exit successfully:
+//
+//	spec.Version("prog 1.2.0", "commit 4f2a9c1")
+//	args, opts, err := spec.Parse(os.Args[1:])
+//	if errors.Is(err, getopt.ErrVersion) {
+//	    os.Exit(0)
+//	}
+//
+// The options are declared, unless they already were, with -V as an
+// alias of --version. -V is left alone if it is declared for another
+// purpose (with an argument, or as an alias of another option); it is
+// not added if --version already has a short alias, as with
+// "version|v" in longopts. Unless described otherwise, the options are
+// described as "print the version and exit".
+func (s *Spec) Version(version string, build ...string) *Spec {
+	version = strings.Join(append([]string{version}, build...), "\n")
+	print := func(optarg OptArg) error {
+		fmt.Fprintln(s.stdout(), version)
+		return &ParseError{
+			Message: "version requested",
+			Opt:     optarg.Option,
+			kind:    ErrVersion,
+		}
+	}
+	s.declareLong("--version", argNone)
+	s.intercepts["--version"] = print
+	names := []string{"--version"}
+	short := ""
+	for alias, long := range s.aliases {
+		if long == "--version" {
+			short = alias
+		}
+	}
+	if ar, has := s.shorts["-V"]; !has && short == "" {
+		s.shorts["-V"] = argNone
+		s.shortNames = append(s.shortNames, "-V")
+		s.aliases["-V"] = "--version"
+	} else if has && ar == argNone && s.aliases["-V"] == "" {
+		s.intercepts["-V"] = print
+		names = append(names, "-V")
+	}
+	for _, name := range names {
+		if _, has := s.descriptions[name]; !has {
+			s.descriptions[name] = "print the version and exit"
+		}
+	}
+	return s
+}
+
+// stdout returns Stdout, or os.Stdout if nil.
+func (s *Spec) stdout() io.Writer {
+	if s.Stdout != nil {
+		return s.Stdout
+	}
+	return os.Stdout
+}
+
+// declareLong declares the long option, unless it already was, with
+// the same arity; for options declared by the Spec methods, such as
+// Version.
+func (s *Spec) declareLong(name string, ar arity) {
+	if declared, has := s.longs[name]; has {
+		if declared != ar {
+			panic(&ParseError{
+				Message:       "option specified more than once",
+				Opt:           name,
+				Unexpected:    q(name),
+				notUsersFault: true,
+				kind:          ErrInvalidSpec,
+			})
+		}
+		return
+	}
+	s.longs[name] = ar
+	s.longNames = append(s.longNames, name)
+	lower := strings.ToLower(name)
+	s.folded[lower] = append(s.folded[lower], name)
+	s.index = newPrefixIndex(s.longNames)
+}
@@ -0,0 +1,73 @@
+package getopt
+
+import "testing"
+import "errors"
+import "strings"
+
+func Test_Spec_Version(t *testing.T) {
+	spec, err := Compile("v", []string{"output="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Required("--output").Version("prog 1.2.0", "commit 4f2a9c1")
+	for _, args := range [][]string{{"-v", "-V", "x"}, {"--version", "-x"}} {
+		var b strings.Builder
+		spec.Stdout = &b
+		_, err := spec.ParseResult(args)
+		errorQA(t, err)
+		if !errors.Is(err, ErrVersion) {
+			t.Log("input", args)
+			t.Log("got", err)
+			t.Fatal("expected the version to be requested")
+		}
+		if b.String() != "prog 1.2.0\ncommit 4f2a9c1\n" {
+			t.Log("input", args)
+			t.Log("got", b.String())
+			t.Fatal("recieved wrong version")
+		}
+	}
+	r, err := spec.ParseResult([]string{"--output=x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Options) != 1 {
+		t.Log("got", r.Options)
+		t.Fatal("recieved wrong options")
+	}
+	if usage := spec.Usage("prog"); usage != "Usage: prog [-v] [--output ARG] [--version] [ARG...]" {
+		t.Log("got", usage)
+		t.Fatal("recieved wrong usage")
+	}
+}
+
+func Test_Spec_Version_declared(t *testing.T) {
+	spec, err := Compile("V:", []string{"version"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Version("1.0")
+	var b strings.Builder
+	spec.Stdout = &b
+	_, opts, err := spec.Parse([]string{"-V", "x"})
+	if err != nil || len(opts) != 1 {
+		t.Log("got", opts, err)
+		t.Fatal("expected -V to be left alone")
+	}
+	spec.CollectErrors = true
+	_, _, err = spec.Parse([]string{"--version"})
+	if !errors.Is(err, ErrVersion) || b.String() != "1.0\n" {
+		t.Log("got", err, b.String())
+		t.Fatal("expected the version to be requested")
+	}
+
+	spec, err = Compile("", []string{"version="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	spec.Version("1.0")
+}